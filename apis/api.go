@@ -2,119 +2,288 @@ package apis
 
 import (
 	"crypto/rsa"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
-	"fmt"
-	"math/big"
+	"errors"
 	"net/http"
+	"project4/engine"
+	"project4/signing"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type Post struct {
-	User      string `json:"user"`
+	Subreddit string `json:"subreddit"`
+	Title     string `json:"title"`
+	Kind      string `json:"kind,omitempty"`
 	Content   string `json:"content"`
-	Signature string `json:"signature"`
+	URL       string `json:"url,omitempty"`
+	NSFW      bool   `json:"nsfw,omitempty"`
+	Spoiler   bool   `json:"spoiler,omitempty"`
+	Flair     string `json:"flair,omitempty"`
 }
 
 type User struct {
 	Username  string `json:"username"`
 	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
 }
 
-var (
-	posts []Post
-	users = make(map[string]string)
-	mu    sync.Mutex
-)
+type PostResponse struct {
+	ID        int        `json:"id"`
+	Subreddit string     `json:"subreddit"`
+	Author    string     `json:"author"`
+	Title     string     `json:"title"`
+	Kind      string     `json:"kind"`
+	Content   string     `json:"content"`
+	URL       string     `json:"url,omitempty"`
+	NSFW      bool       `json:"nsfw"`
+	Spoiler   bool       `json:"spoiler"`
+	Flair     string     `json:"flair,omitempty"`
+	Upvotes   int        `json:"upvotes"`
+	Downvotes int        `json:"downvotes"`
+	Timestamp time.Time  `json:"timestamp"`
+	Edited    *time.Time `json:"edited,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	Removed   bool       `json:"removed,omitempty"`
+	Revisions []Revision `json:"revisions,omitempty"`
+}
+
+type Revision struct {
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+type API struct {
+	engine   *engine.Engine
+	mu       sync.Mutex
+	keys     map[string]*rsa.PublicKey
+	nonces   *nonceCache
+	sessions *sessionStore
+}
+
+func NewAPI(e *engine.Engine) *API {
+	return &API{
+		engine:   e,
+		keys:     make(map[string]*rsa.PublicKey),
+		nonces:   newNonceCache(2 * maxClockSkew),
+		sessions: newSessionStore(e, DefaultSessionTTL),
+	}
+}
 
-func registerUser(w http.ResponseWriter, r *http.Request) {
+func newPostResponse(post engine.Post) PostResponse {
+	return PostResponse{
+		ID:        post.ID,
+		Subreddit: post.Subreddit,
+		Author:    post.Author,
+		Title:     post.Title,
+		Kind:      post.Kind.String(),
+		Content:   post.Content,
+		URL:       post.URL,
+		NSFW:      post.NSFW,
+		Spoiler:   post.Spoiler,
+		Flair:     post.Flair,
+		Upvotes:   post.Upvotes,
+		Downvotes: post.Downvotes,
+		Timestamp: post.Timestamp,
+		Edited:    editedAt(post.Edited),
+		Deleted:   post.Deleted,
+		Removed:   post.Removed,
+		Revisions: newRevisions(post.Revisions),
+	}
+}
+
+func newRevisions(revisions []engine.Revision) []Revision {
+	converted := make([]Revision, 0, len(revisions))
+	for _, revision := range revisions {
+		converted = append(converted, Revision{Content: revision.Content, EditedAt: revision.EditedAt})
+	}
+	return converted
+}
+
+func editedAt(edited time.Time) *time.Time {
+	if edited.IsZero() {
+		return nil
+	}
+	return &edited
+}
+
+func (a *API) registerUser(w http.ResponseWriter, r *http.Request) {
 	var user User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
 
-	mu.Lock()
-	users[user.Username] = user.PublicKey
-	mu.Unlock()
+	if err := engine.ValidateUsername(user.Username); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	response := map[string]string{"status": "registered", "user": user.Username}
+	publicKey, encodedKey, err := proveKeyPossession(user, signing.RegistrationMessage(user.Username))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.engine.RegisterUser(user.Username); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	a.mu.Lock()
+	a.keys[user.Username] = publicKey
+	a.mu.Unlock()
+
+	response := map[string]string{"status": "registered", "user": user.Username, "public_key": encodedKey}
 	json.NewEncoder(w).Encode(response)
 }
 
-func verifySignature(content, signature, publicKey string) bool {
-	pubKeyBytes, _ := base64.StdEncoding.DecodeString(publicKey)
-	hash := sha256.Sum256([]byte(content))
-	sigBytes, _ := base64.StdEncoding.DecodeString(signature)
+func (a *API) publicKey(username string) (*rsa.PublicKey, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	publicKey, exists := a.keys[username]
+	return publicKey, exists
+}
 
-	pubKey := &rsa.PublicKey{
-		N: new(big.Int).SetBytes(pubKeyBytes),
-		E: 65537,
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, engine.ErrUserNotFound),
+		errors.Is(err, engine.ErrSubredditNotFound),
+		errors.Is(err, engine.ErrPostNotFound),
+		errors.Is(err, engine.ErrCommentNotFound),
+		errors.Is(err, engine.ErrMessageNotFound),
+		errors.Is(err, engine.ErrNotBanned),
+		errors.Is(err, engine.ErrNotMuted),
+		errors.Is(err, engine.ErrNotModerator),
+		errors.Is(err, engine.ErrNotMember):
+		return http.StatusNotFound
+	case errors.Is(err, engine.ErrUserExists),
+		errors.Is(err, engine.ErrSubredditExists),
+		errors.Is(err, engine.ErrAlreadyReported):
+		return http.StatusConflict
+	case errors.Is(err, engine.ErrUserNotConnected),
+		errors.Is(err, engine.ErrNotAuthor),
+		errors.Is(err, engine.ErrPermissionDenied),
+		errors.Is(err, engine.ErrBanned),
+		errors.Is(err, engine.ErrMuted),
+		errors.Is(err, engine.ErrPrivateSubreddit),
+		errors.Is(err, engine.ErrNotApproved):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
 	}
-
-	err := rsa.VerifyPKCS1v15(pubKey, 0, hash[:], sigBytes)
-	return err == nil
 }
 
-func createPost(w http.ResponseWriter, r *http.Request) {
+func (a *API) createPost(w http.ResponseWriter, r *http.Request) {
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
 	var post Post
 	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
 
-	mu.Lock()
-	publicKey, exists := users[post.User]
-	mu.Unlock()
-
-	if !exists || !verifySignature(post.Content, post.Signature, publicKey) {
-		http.Error(w, "Signature verification failed", http.StatusUnauthorized)
+	kind, err := engine.ParsePostKind(post.Kind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	mu.Lock()
-	posts = append(posts, post)
-	mu.Unlock()
+	postID, err := a.engine.SubmitPost(username, post.Subreddit, engine.PostDraft{
+		Title:   post.Title,
+		Kind:    kind,
+		Content: post.Content,
+		URL:     post.URL,
+		NSFW:    post.NSFW,
+		Spoiler: post.Spoiler,
+		Flair:   post.Flair,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
 
-	response := map[string]string{"status": "post created", "user": post.User}
+	response := map[string]interface{}{"status": "post created", "user": username, "id": postID}
 	json.NewEncoder(w).Encode(response)
 }
 
-func getPosts(w http.ResponseWriter, r *http.Request) {
-	mu.Lock()
-	defer mu.Unlock()
-	json.NewEncoder(w).Encode(posts)
-}
-
-func getUserPublicKey(w http.ResponseWriter, r *http.Request) {
+func (a *API) getUserPublicKey(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	username := params["username"]
 
-	mu.Lock()
-	publicKey, exists := users[username]
-	mu.Unlock()
-
+	publicKey, exists := a.publicKey(username)
 	if !exists {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
+	encodedKey, err := encodePublicKey(publicKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	response := map[string]string{"username": username, "public_key": publicKey}
+	response := map[string]string{"username": username, "public_key": encodedKey}
 	json.NewEncoder(w).Encode(response)
 }
 
-func StartAPIServer() {
+func (a *API) Router() *mux.Router {
 	router := mux.NewRouter()
-	router.HandleFunc("/register", registerUser).Methods("POST")
-	router.HandleFunc("/post", createPost).Methods("POST")
-	router.HandleFunc("/posts", getPosts).Methods("GET")
-	router.HandleFunc("/user/{username}/publickey", getUserPublicKey).Methods("GET")
-
-	fmt.Println("API Server is running on port :8080...")
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		fmt.Println("Error starting server:", err)
-	}
+	router.HandleFunc("/register", a.registerUser).Methods("POST")
+	router.HandleFunc("/login/challenge", a.loginChallenge).Methods("POST")
+	router.HandleFunc("/login", a.login).Methods("POST")
+	router.HandleFunc("/logout", a.logout).Methods("POST")
+	router.HandleFunc("/post", a.createPost).Methods("POST")
+	router.HandleFunc("/posts", a.getPosts).Methods("GET")
+	router.HandleFunc("/posts/{id}", a.getPost).Methods("GET")
+	router.HandleFunc("/posts/{id}", a.editPost).Methods("PUT")
+	router.HandleFunc("/posts/{id}", a.deletePost).Methods("DELETE")
+	router.HandleFunc("/comments/{id}", a.editComment).Methods("PUT")
+	router.HandleFunc("/comments/{id}", a.deleteComment).Methods("DELETE")
+	router.HandleFunc("/posts/{id}/comments", a.getCommentTree).Methods("GET")
+	router.HandleFunc("/posts/{id}/morechildren", a.getMoreChildren).Methods("GET")
+	router.HandleFunc("/comments/{id}", a.getComment).Methods("GET")
+	router.HandleFunc("/posts/{id}/report", a.reportItem(a.engine.ReportPost)).Methods("POST")
+	router.HandleFunc("/posts/{id}/moderate", a.moderateItem(a.engine.ModeratePost)).Methods("POST")
+	router.HandleFunc("/comments/{id}/report", a.reportItem(a.engine.ReportComment)).Methods("POST")
+	router.HandleFunc("/comments/{id}/moderate", a.moderateItem(a.engine.ModerateComment)).Methods("POST")
+	router.HandleFunc("/user/{username}/publickey", a.getUserPublicKey).Methods("GET")
+	router.HandleFunc("/user/{username}/publickey", a.rotatePublicKey).Methods("PUT")
+	router.HandleFunc("/r", a.listSubreddits).Methods("GET")
+	router.HandleFunc("/r/{name}", a.createSubreddit).Methods("POST")
+	router.HandleFunc("/r/{name}", a.getSubreddit).Methods("GET")
+	router.HandleFunc("/r/{name}", a.updateSubreddit).Methods("PATCH")
+	router.HandleFunc("/r/{name}/join", a.joinSubreddit).Methods("POST")
+	router.HandleFunc("/r/{name}/leave", a.leaveSubreddit).Methods("POST")
+	router.HandleFunc("/r/{name}/members", a.listMembers).Methods("GET")
+	router.HandleFunc("/r/{name}/moderators", a.listModerators).Methods("GET")
+	router.HandleFunc("/r/{name}/moderators/{username}", a.addModerator).Methods("PUT")
+	router.HandleFunc("/r/{name}/moderators/{username}", a.removeModerator).Methods("DELETE")
+	router.HandleFunc("/r/{name}/approved", a.grantAccess(a.engine.ApproveUser, "approved")).Methods("POST")
+	router.HandleFunc("/r/{name}/approved/{username}", a.liftRestriction(a.engine.UnapproveUser, "unapproved")).Methods("DELETE")
+	router.HandleFunc("/r/{name}/invites", a.grantAccess(a.engine.InviteUser, "invited")).Methods("POST")
+	router.HandleFunc("/r/{name}/bans", a.listRestrictions(a.engine.ListBans, "bans")).Methods("GET")
+	router.HandleFunc("/r/{name}/bans", a.restrictUser(a.engine.BanUser, "banned")).Methods("POST")
+	router.HandleFunc("/r/{name}/bans/{username}", a.liftRestriction(a.engine.UnbanUser, "unbanned")).Methods("DELETE")
+	router.HandleFunc("/r/{name}/mutes", a.listRestrictions(a.engine.ListMutes, "mutes")).Methods("GET")
+	router.HandleFunc("/r/{name}/mutes", a.restrictUser(a.engine.MuteUser, "muted")).Methods("POST")
+	router.HandleFunc("/r/{name}/mutes/{username}", a.liftRestriction(a.engine.UnmuteUser, "unmuted")).Methods("DELETE")
+	router.HandleFunc("/r/{name}/modqueue", a.getModQueue).Methods("GET")
+	router.HandleFunc("/r/{name}/filter", a.setFilterWords).Methods("PUT")
+	router.HandleFunc("/r/{sub}/posts/{id}/comments", a.createComment).Methods("POST")
+	router.HandleFunc("/r/{sub}/posts/{id}/comments", a.getComments).Methods("GET")
+	router.HandleFunc("/r/{sub}/posts/{id}/comments/{cid}/replies", a.replyToComment).Methods("POST")
+	router.HandleFunc("/r/{sub}/posts/{id}/comments/{cid}/vote", a.voteComment).Methods("POST")
+	router.HandleFunc("/r/{sub}/posts/{id}/comments/{cid}/vote", a.getCommentVote).Methods("GET")
+	router.HandleFunc("/r/{sub}/posts/{id}/vote", a.votePost).Methods("POST")
+	router.HandleFunc("/r/{sub}/posts/{id}/vote", a.getPostVote).Methods("GET")
+	router.HandleFunc("/messages", a.sendMessage).Methods("POST")
+	router.HandleFunc("/messages/{id}/reply", a.replyToMessage).Methods("POST")
+	router.HandleFunc("/users/{name}/inbox", a.getInbox).Methods("GET")
+	router.HandleFunc("/users/{name}/home", a.getHomeFeed).Methods("GET")
+	return router
 }
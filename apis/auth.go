@@ -0,0 +1,129 @@
+package apis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"project4/signing"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	maxClockSkew   = 5 * time.Minute
+	maxRequestBody = 1 << 20
+)
+
+var (
+	errMissingAuth    = errors.New("missing authentication headers")
+	errStaleRequest   = errors.New("request timestamp outside allowed window")
+	errUnknownSigner  = errors.New("unknown user")
+	errBadSignature   = errors.New("signature verification failed")
+	errReplayedNonce  = errors.New("nonce already used")
+	errInvalidRequest = errors.New("invalid request body")
+)
+
+type nonceCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+func (c *nonceCache) add(username, nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastPrune) > c.ttl/2 {
+		for key, expiry := range c.seen {
+			if now.After(expiry) {
+				delete(c.seen, key)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	key := username + "\n" + nonce
+	if expiry, exists := c.seen[key]; exists && !now.After(expiry) {
+		return false
+	}
+	c.seen[key] = now.Add(c.ttl)
+	return true
+}
+
+func (a *API) authenticate(r *http.Request) (string, error) {
+	if token := bearerToken(r); token != "" {
+		return a.authenticateSession(token)
+	}
+	username, err := a.authenticateSignature(r)
+	if err != nil {
+		return "", err
+	}
+	if !a.engine.IsConnected(username) {
+		a.engine.ConnectUser(username)
+	}
+	return username, nil
+}
+
+func (a *API) authenticateSignature(r *http.Request) (string, error) {
+	username := r.Header.Get(signing.HeaderUser)
+	timestamp := r.Header.Get(signing.HeaderTimestamp)
+	nonce := r.Header.Get(signing.HeaderNonce)
+	signature := r.Header.Get(signing.HeaderSignature)
+	if username == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", errMissingAuth
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errStaleRequest, err)
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > maxClockSkew || skew < -maxClockSkew {
+		return "", errStaleRequest
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxRequestBody))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errInvalidRequest, err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	publicKey, exists := a.publicKey(username)
+	if !exists {
+		return "", errUnknownSigner
+	}
+
+	message := signing.CanonicalRequest(r.Method, r.URL.RequestURI(), body, timestamp, nonce)
+	if err := signing.Verify(publicKey, message, signature); err != nil {
+		return "", errBadSignature
+	}
+
+	if !a.nonces.add(username, nonce, now) {
+		return "", errReplayedNonce
+	}
+	return username, nil
+}
+
+func (a *API) requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username, err := a.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return "", false
+	}
+	return username, true
+}
+
+func (a *API) optionalUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if bearerToken(r) == "" && r.Header.Get(signing.HeaderUser) == "" {
+		return "", true
+	}
+	return a.requireUser(w, r)
+}
@@ -0,0 +1,114 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"project4/engine"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type RestrictionRequest struct {
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration,omitempty"`
+}
+
+type RestrictionResponse struct {
+	Username  string     `json:"username"`
+	Moderator string     `json:"moderator"`
+	Reason    string     `json:"reason,omitempty"`
+	Created   time.Time  `json:"created"`
+	Expires   *time.Time `json:"expires,omitempty"`
+}
+
+type restrictFunc func(moderator, subreddit, username, reason string, duration time.Duration) error
+type liftFunc func(moderator, subreddit, username string) error
+type listRestrictionsFunc func(moderator, subreddit string) ([]engine.Restriction, error)
+
+func newRestrictionResponse(restriction engine.Restriction) RestrictionResponse {
+	response := RestrictionResponse{
+		Username:  restriction.Username,
+		Moderator: restriction.Moderator,
+		Reason:    restriction.Reason,
+		Created:   restriction.Created,
+	}
+	if !restriction.Permanent() {
+		response.Expires = &restriction.Expires
+	}
+	return response
+}
+
+func (a *API) restrictUser(restrict restrictFunc, status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		moderator, ok := a.requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req RestrictionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		var duration time.Duration
+		if req.Duration != "" {
+			parsed, err := time.ParseDuration(req.Duration)
+			if err != nil {
+				http.Error(w, engine.ErrInvalidDuration.Error(), http.StatusBadRequest)
+				return
+			}
+			duration = parsed
+		}
+
+		if err := restrict(moderator, name, req.Username, req.Reason, duration); err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+
+		response := map[string]string{"status": status, "subreddit": name, "user": req.Username}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (a *API) liftRestriction(lift liftFunc, status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		moderator, ok := a.requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := lift(moderator, vars["name"], vars["username"]); err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+
+		response := map[string]string{"status": status, "subreddit": vars["name"], "user": vars["username"]}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (a *API) listRestrictions(list listRestrictionsFunc, key string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		moderator, ok := a.requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		restrictions, err := list(moderator, name)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+
+		response := make([]RestrictionResponse, 0, len(restrictions))
+		for _, restriction := range restrictions {
+			response = append(response, newRestrictionResponse(restriction))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"subreddit": name, key: response})
+	}
+}
@@ -0,0 +1,324 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"project4/engine"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Comment struct {
+	Content string `json:"content"`
+}
+
+type CommentResponse struct {
+	ID        int               `json:"id"`
+	PostID    int               `json:"post_id"`
+	ParentID  int               `json:"parent_id,omitempty"`
+	Author    string            `json:"author"`
+	Content   string            `json:"content"`
+	Upvotes   int               `json:"upvotes"`
+	Downvotes int               `json:"downvotes"`
+	Score     int               `json:"score"`
+	Timestamp time.Time         `json:"timestamp"`
+	Edited    *time.Time        `json:"edited,omitempty"`
+	Deleted   bool              `json:"deleted,omitempty"`
+	Removed   bool              `json:"removed,omitempty"`
+	Revisions []Revision        `json:"revisions,omitempty"`
+	Replies   []CommentResponse `json:"replies"`
+}
+
+func newCommentResponses(comments []*engine.Comment) []CommentResponse {
+	responses := make([]CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, CommentResponse{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			ParentID:  comment.ParentID,
+			Author:    comment.Author,
+			Content:   comment.Content,
+			Upvotes:   comment.Upvotes,
+			Downvotes: comment.Downvotes,
+			Score:     comment.Upvotes - comment.Downvotes,
+			Timestamp: comment.Timestamp,
+			Edited:    editedAt(comment.Edited),
+			Deleted:   comment.Deleted,
+			Removed:   comment.Removed,
+			Revisions: newRevisions(comment.Revisions),
+			Replies:   newCommentResponses(comment.Replies),
+		})
+	}
+	return responses
+}
+
+type CommentNodeResponse struct {
+	ID        int                   `json:"id"`
+	PostID    int                   `json:"post_id"`
+	ParentID  int                   `json:"parent_id,omitempty"`
+	Author    string                `json:"author"`
+	Content   string                `json:"content"`
+	Upvotes   int                   `json:"upvotes"`
+	Downvotes int                   `json:"downvotes"`
+	Score     int                   `json:"score"`
+	Timestamp time.Time             `json:"timestamp"`
+	Edited    *time.Time            `json:"edited,omitempty"`
+	Deleted   bool                  `json:"deleted,omitempty"`
+	Removed   bool                  `json:"removed,omitempty"`
+	Depth     int                   `json:"depth"`
+	Replies   []CommentNodeResponse `json:"replies"`
+	More      *MoreChildrenResponse `json:"more,omitempty"`
+}
+
+type MoreChildrenResponse struct {
+	ParentID int   `json:"parent_id"`
+	Count    int   `json:"count"`
+	Children []int `json:"children"`
+}
+
+type CommentTreeResponse struct {
+	PostID   int                   `json:"post_id"`
+	Comments []CommentNodeResponse `json:"comments"`
+	More     *MoreChildrenResponse `json:"more,omitempty"`
+}
+
+func newMoreChildrenResponse(more *engine.MoreChildren) *MoreChildrenResponse {
+	if more == nil {
+		return nil
+	}
+	return &MoreChildrenResponse{ParentID: more.ParentID, Count: more.Count, Children: more.Children}
+}
+
+func newCommentNodeResponses(nodes []*engine.CommentNode) []CommentNodeResponse {
+	responses := make([]CommentNodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		responses = append(responses, CommentNodeResponse{
+			ID:        node.ID,
+			PostID:    node.PostID,
+			ParentID:  node.ParentID,
+			Author:    node.Author,
+			Content:   node.Content,
+			Upvotes:   node.Upvotes,
+			Downvotes: node.Downvotes,
+			Score:     node.Upvotes - node.Downvotes,
+			Timestamp: node.Timestamp,
+			Edited:    editedAt(node.Edited),
+			Deleted:   node.Deleted,
+			Removed:   node.Removed,
+			Depth:     node.Depth,
+			Replies:   newCommentNodeResponses(node.Replies),
+			More:      newMoreChildrenResponse(node.More),
+		})
+	}
+	return responses
+}
+
+func newCommentTreeResponse(tree engine.CommentTree) CommentTreeResponse {
+	return CommentTreeResponse{
+		PostID:   tree.PostID,
+		Comments: newCommentNodeResponses(tree.Comments),
+		More:     newMoreChildrenResponse(tree.More),
+	}
+}
+
+func parseCommentTreeQuery(r *http.Request) (engine.CommentSort, int, int, bool) {
+	query := r.URL.Query()
+	sortBy := engine.CommentBest
+	if name := query.Get("sort"); name != "" {
+		parsed, err := engine.ParseCommentSort(name)
+		if err != nil {
+			return sortBy, 0, 0, false
+		}
+		sortBy = parsed
+	}
+
+	depth, limit := 0, 0
+	for name, target := range map[string]*int{"depth": &depth, "limit": &limit} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				return sortBy, 0, 0, false
+			}
+			*target = parsed
+		}
+	}
+	return sortBy, depth, limit, true
+}
+
+func pathInt(r *http.Request, name string) (int, bool) {
+	value, err := strconv.Atoi(mux.Vars(r)[name])
+	return value, err == nil
+}
+
+func (a *API) decodeComment(w http.ResponseWriter, r *http.Request) (string, Comment, bool) {
+	var comment Comment
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return "", comment, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return "", comment, false
+	}
+	return username, comment, true
+}
+
+func (a *API) createComment(w http.ResponseWriter, r *http.Request) {
+	subreddit := mux.Vars(r)["sub"]
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	username, comment, ok := a.decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	commentID, err := a.engine.CommentOnPost(username, subreddit, postID, comment.Content)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]interface{}{"status": "comment created", "user": username, "id": commentID}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) replyToComment(w http.ResponseWriter, r *http.Request) {
+	subreddit := mux.Vars(r)["sub"]
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	commentID, ok := pathInt(r, "cid")
+	if !ok {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	username, comment, ok := a.decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	replyID, err := a.engine.ReplyToComment(subreddit, postID, commentID, username, comment.Content)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]interface{}{"status": "reply created", "user": username, "id": replyID, "parent_id": commentID}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) getComments(w http.ResponseWriter, r *http.Request) {
+	subreddit := mux.Vars(r)["sub"]
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := a.engine.GetComments(viewer, subreddit, postID)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(newCommentResponses(comments))
+}
+
+func (a *API) getComment(w http.ResponseWriter, r *http.Request) {
+	commentID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := a.engine.GetCommentAs(viewer, commentID)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(newCommentResponses([]*engine.Comment{&comment})[0])
+}
+
+func (a *API) getCommentTree(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	sortBy, depth, limit, ok := parseCommentTreeQuery(r)
+	if !ok {
+		http.Error(w, "Invalid sort, depth or limit", http.StatusBadRequest)
+		return
+	}
+
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+
+	tree, err := a.engine.GetCommentTreeAs(viewer, postID, sortBy, depth, limit)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newCommentTreeResponse(tree))
+}
+
+func (a *API) getMoreChildren(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	sortBy, depth, limit, ok := parseCommentTreeQuery(r)
+	if !ok {
+		http.Error(w, "Invalid sort, depth or limit", http.StatusBadRequest)
+		return
+	}
+
+	children := []int{}
+	for _, value := range strings.Split(r.URL.Query().Get("children"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			http.Error(w, "Invalid children list", http.StatusBadRequest)
+			return
+		}
+		children = append(children, id)
+	}
+
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+
+	tree, err := a.engine.GetMoreChildrenAs(viewer, postID, children, sortBy, depth, limit)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newCommentTreeResponse(tree))
+}
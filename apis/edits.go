@@ -0,0 +1,89 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"project4/engine"
+)
+
+func (a *API) editPost(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	username, edit, ok := a.decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := a.engine.EditPost(username, postID, edit.Content)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newPostResponse(post))
+}
+
+func (a *API) deletePost(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.engine.DeletePost(username, postID); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]interface{}{"status": "post deleted", "user": username, "id": postID}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) editComment(w http.ResponseWriter, r *http.Request) {
+	commentID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	username, edit, ok := a.decodeComment(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := a.engine.EditComment(username, commentID, edit.Content)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newCommentResponses([]*engine.Comment{&comment})[0])
+}
+
+func (a *API) deleteComment(w http.ResponseWriter, r *http.Request) {
+	commentID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.engine.DeleteComment(username, commentID); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]interface{}{"status": "comment deleted", "user": username, "id": commentID}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,140 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project4/engine"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type FeedResponse struct {
+	Posts  []PostResponse `json:"posts"`
+	After  string         `json:"after,omitempty"`
+	Before string         `json:"before,omitempty"`
+	Total  int            `json:"total"`
+}
+
+func newFeedResponse(page engine.FeedPage) FeedResponse {
+	posts := make([]PostResponse, 0, len(page.Posts))
+	for _, post := range page.Posts {
+		posts = append(posts, newPostResponse(post))
+	}
+	return FeedResponse{Posts: posts, After: page.After, Before: page.Before, Total: page.Total}
+}
+
+var errInvalidLimit = errors.New("limit must be a positive integer")
+
+func parseFeedOptions(r *http.Request, defaultSort engine.FeedSort) (engine.FeedOptions, error) {
+	query := r.URL.Query()
+	options := engine.FeedOptions{
+		Sort:   defaultSort,
+		Window: engine.WindowAll,
+		After:  query.Get("after"),
+		Before: query.Get("before"),
+		Flair:  query.Get("flair"),
+	}
+
+	if name := query.Get("sort"); name != "" {
+		sortBy, err := engine.ParseFeedSort(name)
+		if err != nil {
+			return options, err
+		}
+		options.Sort = sortBy
+	}
+	if name := query.Get("t"); name != "" {
+		window, err := engine.ParseTimeWindow(name)
+		if err != nil {
+			return options, err
+		}
+		options.Window = window
+	}
+	if name := query.Get("nsfw"); name != "" {
+		filter, err := engine.ParseNSFWFilter(name)
+		if err != nil {
+			return options, err
+		}
+		options.NSFW = filter
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return options, errInvalidLimit
+		}
+		options.Limit = limit
+	}
+	return options, nil
+}
+
+func (a *API) getPosts(w http.ResponseWriter, r *http.Request) {
+	options, err := parseFeedOptions(r, engine.SortNew)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+	options.Viewer = viewer
+
+	var page engine.FeedPage
+	if name := r.URL.Query().Get("subreddit"); name != "" {
+		page, err = a.engine.QueryFeed(name, options)
+	} else {
+		page, err = a.engine.QueryAllFeed(options)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newFeedResponse(page))
+}
+
+func (a *API) getPost(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := a.engine.GetPostAs(viewer, postID)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newPostResponse(post))
+}
+
+func (a *API) getHomeFeed(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["name"]
+
+	requester, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+	if requester != username {
+		http.Error(w, "Cannot read another user's home feed", http.StatusForbidden)
+		return
+	}
+
+	options, err := parseFeedOptions(r, engine.SortHot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := a.engine.QueryHomeFeed(username, options)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newFeedResponse(page))
+}
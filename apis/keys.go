@@ -0,0 +1,164 @@
+package apis
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"project4/signing"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	minKeyBits     = 2048
+	legacyExponent = 65537
+)
+
+var (
+	errInvalidPublicKey = errors.New("invalid public key")
+	errMissingProof     = errors.New("key possession proof failed: sign the username with the submitted key")
+)
+
+func parsePublicKey(encoded string) (*rsa.PublicKey, error) {
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, fmt.Errorf("%w: key is empty", errInvalidPublicKey)
+	}
+
+	if strings.HasPrefix(encoded, "-----BEGIN") {
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			return nil, fmt.Errorf("%w: malformed PEM block", errInvalidPublicKey)
+		}
+		switch block.Type {
+		case "PUBLIC KEY":
+			return parsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			return parsePKCS1PublicKey(block.Bytes)
+		default:
+			return nil, fmt.Errorf("%w: unsupported PEM type %q", errInvalidPublicKey, block.Type)
+		}
+	}
+
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidPublicKey, err)
+	}
+	if key, err := parsePKIXPublicKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := parsePKCS1PublicKey(der); err == nil {
+		return key, nil
+	}
+	return parseLegacyModulus(der)
+}
+
+func parsePKIXPublicKey(der []byte) (*rsa.PublicKey, error) {
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidPublicKey, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: only RSA keys are supported", errInvalidPublicKey)
+	}
+	return checkKeySize(key)
+}
+
+func parsePKCS1PublicKey(der []byte) (*rsa.PublicKey, error) {
+	key, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidPublicKey, err)
+	}
+	return checkKeySize(key)
+}
+
+func parseLegacyModulus(modulus []byte) (*rsa.PublicKey, error) {
+	key := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: legacyExponent,
+	}
+	return checkKeySize(key)
+}
+
+func checkKeySize(key *rsa.PublicKey) (*rsa.PublicKey, error) {
+	if key.N.BitLen() < minKeyBits {
+		return nil, fmt.Errorf("%w: key must be at least %d bits", errInvalidPublicKey, minKeyBits)
+	}
+	return key, nil
+}
+
+func encodePublicKey(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+func proveKeyPossession(user User, message string) (*rsa.PublicKey, string, error) {
+	publicKey, err := parsePublicKey(user.PublicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	encodedKey, err := encodePublicKey(publicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := signing.Verify(publicKey, message, user.Signature); err != nil {
+		return nil, "", errMissingProof
+	}
+	return publicKey, encodedKey, nil
+}
+
+func (a *API) rotatePublicKey(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	signer, err := a.authenticateSignature(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if signer != username {
+		http.Error(w, "Cannot rotate another user's key", http.StatusForbidden)
+		return
+	}
+
+	var user User
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	user.Username = username
+
+	currentKey, _ := a.publicKey(username)
+	fingerprint, err := signing.KeyFingerprint(currentKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	publicKey, encodedKey, err := proveKeyPossession(user, signing.RotationMessage(username, fingerprint))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	if a.keys[username] != currentKey {
+		a.mu.Unlock()
+		http.Error(w, "Key was rotated concurrently", http.StatusConflict)
+		return
+	}
+	a.keys[username] = publicKey
+	a.mu.Unlock()
+	a.sessions.revokeUser(username)
+
+	response := map[string]string{"status": "key rotated", "user": username, "public_key": encodedKey}
+	json.NewEncoder(w).Encode(response)
+}
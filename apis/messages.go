@@ -0,0 +1,112 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"project4/engine"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type DirectMessage struct {
+	Receiver string `json:"receiver"`
+	Content  string `json:"content"`
+}
+
+type MessageResponse struct {
+	ID        int       `json:"id"`
+	ParentID  int       `json:"parent_id,omitempty"`
+	Sender    string    `json:"sender"`
+	Receiver  string    `json:"receiver"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func newMessageResponses(messages []engine.Message) []MessageResponse {
+	responses := make([]MessageResponse, 0, len(messages))
+	for _, message := range messages {
+		responses = append(responses, MessageResponse{
+			ID:        message.ID,
+			ParentID:  message.ParentID,
+			Sender:    message.Sender,
+			Receiver:  message.Receiver,
+			Content:   message.Content,
+			Timestamp: message.Timestamp,
+		})
+	}
+	return responses
+}
+
+func (a *API) sendMessage(w http.ResponseWriter, r *http.Request) {
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var message DirectMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	messageID, err := a.engine.SendMessage(username, message.Receiver, message.Content)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]interface{}{"status": "message sent", "id": messageID, "receiver": message.Receiver}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) replyToMessage(w http.ResponseWriter, r *http.Request) {
+	messageID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var reply DirectMessage
+	if err := json.NewDecoder(r.Body).Decode(&reply); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	replyID, err := a.engine.ReplyToMessage(username, messageID, reply.Content)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]interface{}{"status": "reply sent", "id": replyID, "parent_id": messageID}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) getInbox(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["name"]
+
+	requester, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+	if requester != username {
+		http.Error(w, "Cannot read another user's inbox", http.StatusForbidden)
+		return
+	}
+
+	messages, err := a.engine.ListMessages(username)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(newMessageResponses(messages))
+}
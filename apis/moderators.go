@@ -0,0 +1,115 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"project4/engine"
+
+	"github.com/gorilla/mux"
+)
+
+type ModeratorRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type ModeratorResponse struct {
+	Username    string   `json:"username"`
+	Permissions []string `json:"permissions"`
+	Owner       bool     `json:"owner,omitempty"`
+}
+
+type SubredditSettingsRequest struct {
+	Description string `json:"description"`
+}
+
+func newModeratorResponse(moderator engine.Moderator) ModeratorResponse {
+	return ModeratorResponse{
+		Username:    moderator.Username,
+		Permissions: moderator.Permissions.Names(),
+		Owner:       moderator.Owner,
+	}
+}
+
+func (a *API) listModerators(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+
+	moderators, err := a.engine.ListModerators(viewer, name)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := make([]ModeratorResponse, 0, len(moderators))
+	for _, moderator := range moderators {
+		response = append(response, newModeratorResponse(moderator))
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"subreddit": name, "moderators": response})
+}
+
+func (a *API) addModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req ModeratorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	permissions, err := engine.ParseModPermissions(req.Permissions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.engine.AddModerator(username, vars["name"], vars["username"], permissions); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := ModeratorResponse{Username: vars["username"], Permissions: permissions.Names()}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) removeModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.engine.RemoveModerator(username, vars["name"], vars["username"]); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]string{"status": "moderator removed", "subreddit": vars["name"], "user": vars["username"]}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) updateSubreddit(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req SubredditSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	info, err := a.engine.UpdateSubreddit(username, name, engine.SubredditSettings{Description: req.Description})
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newSubredditResponse(info))
+}
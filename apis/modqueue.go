@@ -0,0 +1,163 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"project4/engine"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type ReportRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ModerateRequest struct {
+	Action string `json:"action"`
+}
+
+type FilterRequest struct {
+	Words []string `json:"words"`
+}
+
+type ReportResponse struct {
+	Reporter string    `json:"reporter"`
+	Reason   string    `json:"reason"`
+	Created  time.Time `json:"created"`
+}
+
+type QueueItemResponse struct {
+	Subreddit string           `json:"subreddit"`
+	PostID    int              `json:"post_id"`
+	CommentID int              `json:"comment_id,omitempty"`
+	Author    string           `json:"author"`
+	Title     string           `json:"title,omitempty"`
+	Content   string           `json:"content"`
+	Timestamp time.Time        `json:"timestamp"`
+	Removed   bool             `json:"removed,omitempty"`
+	Filtered  bool             `json:"filtered,omitempty"`
+	Reports   []ReportResponse `json:"reports"`
+}
+
+type reportFunc func(reporter string, id int, reason string) error
+type moderateFunc func(moderator string, id int, action engine.ModAction) error
+
+func newQueueItemResponse(item engine.QueueItem) QueueItemResponse {
+	reports := make([]ReportResponse, 0, len(item.Reports))
+	for _, report := range item.Reports {
+		reports = append(reports, ReportResponse{Reporter: report.Reporter, Reason: report.Reason, Created: report.Created})
+	}
+	return QueueItemResponse{
+		Subreddit: item.Subreddit,
+		PostID:    item.PostID,
+		CommentID: item.CommentID,
+		Author:    item.Author,
+		Title:     item.Title,
+		Content:   item.Content,
+		Timestamp: item.Timestamp,
+		Removed:   item.Removed,
+		Filtered:  item.Filtered,
+		Reports:   reports,
+	}
+}
+
+func (a *API) reportItem(report reportFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathInt(r, "id")
+		if !ok {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		username, ok := a.requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req ReportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if err := report(username, id, req.Reason); err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "reported", "id": id})
+	}
+}
+
+func (a *API) moderateItem(moderate moderateFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathInt(r, "id")
+		if !ok {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		username, ok := a.requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req ModerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		action, err := engine.ParseModAction(req.Action)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := moderate(username, id, action); err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": action.String(), "id": id})
+	}
+}
+
+func (a *API) getModQueue(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	queue, err := a.engine.GetModQueue(username, name)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := make([]QueueItemResponse, 0, len(queue))
+	for _, item := range queue {
+		response = append(response, newQueueItemResponse(item))
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"subreddit": name, "queue": response})
+}
+
+func (a *API) setFilterWords(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req FilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.engine.SetFilterWords(username, name, req.Words); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "updated", "subreddit": name})
+}
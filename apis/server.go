@@ -0,0 +1,138 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"project4/engine"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAddr         = ":8080"
+	DefaultReadTimeout  = 10 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+type Config struct {
+	Addr         string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	SessionTTL   time.Duration
+	Engine       *engine.Engine
+}
+
+type Server struct {
+	config     Config
+	api        *API
+	handler    http.Handler
+	httpServer *http.Server
+	ready      chan struct{}
+	readyOnce  sync.Once
+	stop       chan struct{}
+	stopOnce   sync.Once
+	mu         sync.Mutex
+	listenAddr net.Addr
+}
+
+func NewServer(config Config) *Server {
+	if config.Addr == "" {
+		config.Addr = DefaultAddr
+	}
+	if config.ReadTimeout == 0 {
+		config.ReadTimeout = DefaultReadTimeout
+	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+	if config.SessionTTL <= 0 {
+		config.SessionTTL = DefaultSessionTTL
+	}
+	if config.Engine == nil {
+		config.Engine = engine.NewEngine()
+	}
+
+	api := NewAPI(config.Engine)
+	api.sessions = newSessionStore(config.Engine, config.SessionTTL)
+	handler := api.Router()
+	return &Server{
+		config:  config,
+		api:     api,
+		handler: handler,
+		httpServer: &http.Server{
+			Addr:         config.Addr,
+			Handler:      handler,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+		},
+		ready: make(chan struct{}),
+		stop:  make(chan struct{}),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *Server) Engine() *engine.Engine {
+	return s.config.Engine
+}
+
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listenAddr == nil {
+		return s.config.Addr
+	}
+	return s.listenAddr.String()
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.config.Addr, err)
+	}
+
+	s.mu.Lock()
+	s.listenAddr = listener.Addr()
+	s.mu.Unlock()
+	s.readyOnce.Do(func() { close(s.ready) })
+	go s.sweepSessions()
+
+	fmt.Printf("API Server is running on %s...\n", listener.Addr())
+	if err := s.httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) ExpireSessions(now time.Time) {
+	s.api.ExpireSessions(now)
+}
+
+func (s *Server) sweepSessions() {
+	ticker := time.NewTicker(s.config.SessionTTL / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.api.ExpireSessions(now)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.httpServer.Shutdown(ctx)
+}
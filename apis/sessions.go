@@ -0,0 +1,269 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project4/engine"
+	"project4/signing"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSessionTTL = 30 * time.Minute
+	challengeTTL      = time.Minute
+)
+
+var (
+	errNoChallenge    = errors.New("no pending login challenge")
+	errInvalidSession = errors.New("invalid or expired session token")
+)
+
+type LoginChallengeRequest struct {
+	Username string `json:"username"`
+}
+
+type LoginRequest struct {
+	Username  string `json:"username"`
+	Challenge string `json:"challenge"`
+	Signature string `json:"signature"`
+}
+
+type session struct {
+	username  string
+	expiresAt time.Time
+}
+
+type challenge struct {
+	value     string
+	username  string
+	expiresAt time.Time
+}
+
+type sessionStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	engine     *engine.Engine
+	sessions   map[string]session
+	live       map[string]int
+	challenges map[string]challenge
+	lastSweep  time.Time
+}
+
+func newSessionStore(e *engine.Engine, ttl time.Duration) *sessionStore {
+	return &sessionStore{
+		ttl:        ttl,
+		engine:     e,
+		sessions:   make(map[string]session),
+		live:       make(map[string]int),
+		challenges: make(map[string]challenge),
+	}
+}
+
+func (s *sessionStore) issueChallenge(username string, now time.Time) (challenge, error) {
+	value, err := signing.NewNonce()
+	if err != nil {
+		return challenge{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := challenge{value: value, username: username, expiresAt: now.Add(challengeTTL)}
+	s.challenges[value] = c
+	return c, nil
+}
+
+func (s *sessionStore) consumeChallenge(username, value string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, exists := s.challenges[value]
+	if !exists || c.username != username {
+		return false
+	}
+	delete(s.challenges, value)
+	return now.Before(c.expiresAt)
+}
+
+func (s *sessionStore) create(username string, now time.Time) (string, session, error) {
+	token, err := signing.NewToken()
+	if err != nil {
+		return "", session{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.engine.ConnectUser(username); err != nil {
+		return "", session{}, err
+	}
+	sess := session{username: username, expiresAt: now.Add(s.ttl)}
+	s.sessions[token] = sess
+	s.live[username]++
+	return token, sess, nil
+}
+
+func (s *sessionStore) drop(token string, sess session) {
+	delete(s.sessions, token)
+	if s.live[sess.username]--; s.live[sess.username] <= 0 {
+		delete(s.live, sess.username)
+		s.engine.DisconnectUser(sess.username)
+	}
+}
+
+func (s *sessionStore) lookup(token string, now time.Time) (session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, exists := s.sessions[token]
+	if !exists {
+		return session{}, false
+	}
+	if !now.Before(sess.expiresAt) {
+		s.drop(token, sess)
+		return session{}, false
+	}
+	return sess, true
+}
+
+func (s *sessionStore) sweepDue(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return now.Sub(s.lastSweep) >= s.ttl/4
+}
+
+func (s *sessionStore) revoke(token string) (session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, exists := s.sessions[token]
+	if exists {
+		s.drop(token, sess)
+	}
+	return sess, exists
+}
+
+func (s *sessionStore) revokeUser(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, sess := range s.sessions {
+		if sess.username == username {
+			delete(s.sessions, token)
+		}
+	}
+	for value, c := range s.challenges {
+		if c.username == username {
+			delete(s.challenges, value)
+		}
+	}
+	delete(s.live, username)
+	s.engine.DisconnectUser(username)
+}
+
+func (s *sessionStore) expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSweep = now
+	for token, sess := range s.sessions {
+		if !now.Before(sess.expiresAt) {
+			s.drop(token, sess)
+		}
+	}
+	for value, c := range s.challenges {
+		if !now.Before(c.expiresAt) {
+			delete(s.challenges, value)
+		}
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+}
+
+func (a *API) ExpireSessions(now time.Time) {
+	a.sessions.expire(now)
+}
+
+func (a *API) authenticateSession(token string) (string, error) {
+	now := time.Now()
+	if a.sessions.sweepDue(now) {
+		a.ExpireSessions(now)
+	}
+	sess, ok := a.sessions.lookup(token, now)
+	if !ok {
+		return "", errInvalidSession
+	}
+	return sess.username, nil
+}
+
+func (a *API) loginChallenge(w http.ResponseWriter, r *http.Request) {
+	var req LoginChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if _, exists := a.publicKey(req.Username); !exists {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	c, err := a.sessions.issueChallenge(req.Username, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{"username": req.Username, "challenge": c.value, "expires_at": c.expiresAt}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	publicKey, exists := a.publicKey(req.Username)
+	if !exists {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	now := time.Now()
+	if !a.sessions.consumeChallenge(req.Username, req.Challenge, now) {
+		http.Error(w, errNoChallenge.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err := signing.Verify(publicKey, signing.LoginMessage(req.Username, req.Challenge), req.Signature); err != nil {
+		http.Error(w, errBadSignature.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	token, sess, err := a.sessions.create(req.Username, now)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]interface{}{"username": req.Username, "token": token, "expires_at": sess.expiresAt}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) logout(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	sess, exists := a.sessions.revoke(token)
+	if token == "" || !exists {
+		http.Error(w, errInvalidSession.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response := map[string]string{"status": "logged out", "user": sess.username}
+	json.NewEncoder(w).Encode(response)
+}
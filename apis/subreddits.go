@@ -0,0 +1,165 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"project4/engine"
+
+	"github.com/gorilla/mux"
+)
+
+type CreateSubredditRequest struct {
+	Type string `json:"type"`
+}
+
+type SubredditUserRequest struct {
+	Username string `json:"username"`
+}
+
+type SubredditResponse struct {
+	Name        string `json:"name"`
+	Owner       string `json:"owner,omitempty"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	MemberCount int    `json:"member_count"`
+	PostCount   int    `json:"post_count"`
+}
+
+func newSubredditResponse(info engine.SubredditInfo) SubredditResponse {
+	return SubredditResponse{
+		Name:        info.Name,
+		Owner:       info.Owner,
+		Type:        info.Type.String(),
+		Description: info.Description,
+		MemberCount: info.MemberCount,
+		PostCount:   info.PostCount,
+	}
+}
+
+func (a *API) createSubreddit(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateSubredditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	kind, err := engine.ParseSubredditType(req.Type)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.engine.CreateSubredditAs(username, name, kind); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]string{"status": "subreddit created", "subreddit": name, "owner": username, "type": kind.String()}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) listSubreddits(w http.ResponseWriter, r *http.Request) {
+	subreddits := []SubredditResponse{}
+	for _, name := range a.engine.ListSubreddits() {
+		info, err := a.engine.GetSubreddit(name)
+		if err != nil {
+			continue
+		}
+		subreddits = append(subreddits, newSubredditResponse(info))
+	}
+	json.NewEncoder(w).Encode(subreddits)
+}
+
+func (a *API) getSubreddit(w http.ResponseWriter, r *http.Request) {
+	info, err := a.engine.GetSubreddit(mux.Vars(r)["name"])
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	json.NewEncoder(w).Encode(newSubredditResponse(info))
+}
+
+func (a *API) joinSubreddit(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.engine.JoinSubreddit(username, name); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]string{"status": "joined", "subreddit": name, "user": username}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) leaveSubreddit(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.engine.LeaveSubreddit(username, name); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]string{"status": "left", "subreddit": name, "user": username}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) listMembers(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	viewer, ok := a.optionalUser(w, r)
+	if !ok {
+		return
+	}
+	if err := a.engine.CheckSubredditAccess(viewer, name); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	members, err := a.engine.ListMembers(name)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]interface{}{"subreddit": name, "members": members}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) grantAccess(grant func(moderator, subreddit, username string) error, status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		moderator, ok := a.requireUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req SubredditUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if err := grant(moderator, name, req.Username); err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+
+		response := map[string]string{"status": status, "subreddit": name, "user": req.Username}
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,180 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"project4/engine"
+
+	"github.com/gorilla/mux"
+)
+
+type Vote struct {
+	Direction string `json:"direction"`
+}
+
+type VoteResponse struct {
+	PostID      int    `json:"post_id"`
+	CommentID   int    `json:"comment_id,omitempty"`
+	Direction   string `json:"direction"`
+	Upvotes     int    `json:"upvotes"`
+	Downvotes   int    `json:"downvotes"`
+	Score       int    `json:"score"`
+	Author      string `json:"author"`
+	AuthorKarma int    `json:"author_karma"`
+}
+
+var voteDirections = map[string]engine.VoteDirection{
+	"up":    engine.VoteUp,
+	"down":  engine.VoteDown,
+	"clear": engine.VoteNone,
+}
+
+func voteDirectionName(direction engine.VoteDirection) string {
+	if direction == engine.VoteNone {
+		return "none"
+	}
+	for name, value := range voteDirections {
+		if value == direction {
+			return name
+		}
+	}
+	return ""
+}
+
+func (a *API) getPostVote(w http.ResponseWriter, r *http.Request) {
+	subreddit := mux.Vars(r)["sub"]
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	direction, err := a.engine.GetPostVote(username, subreddit, postID)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]interface{}{"post_id": postID, "user": username, "direction": voteDirectionName(direction)}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *API) decodeVote(w http.ResponseWriter, r *http.Request) (string, Vote, engine.VoteDirection, bool) {
+	var vote Vote
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return "", vote, engine.VoteNone, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&vote); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return "", vote, engine.VoteNone, false
+	}
+	direction, ok := voteDirections[vote.Direction]
+	if !ok {
+		http.Error(w, "Direction must be up, down or clear", http.StatusBadRequest)
+		return "", vote, engine.VoteNone, false
+	}
+	return username, vote, direction, true
+}
+
+func (a *API) votePost(w http.ResponseWriter, r *http.Request) {
+	subreddit := mux.Vars(r)["sub"]
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	username, vote, direction, ok := a.decodeVote(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := a.engine.VotePost(username, subreddit, postID, direction)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	authorKarma, _ := a.engine.GetUserKarma(post.Author)
+
+	json.NewEncoder(w).Encode(VoteResponse{
+		PostID:      post.ID,
+		Direction:   vote.Direction,
+		Upvotes:     post.Upvotes,
+		Downvotes:   post.Downvotes,
+		Score:       post.Upvotes - post.Downvotes,
+		Author:      post.Author,
+		AuthorKarma: authorKarma,
+	})
+}
+
+func (a *API) voteComment(w http.ResponseWriter, r *http.Request) {
+	subreddit := mux.Vars(r)["sub"]
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	commentID, ok := pathInt(r, "cid")
+	if !ok {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	username, vote, direction, ok := a.decodeVote(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := a.engine.VoteComment(username, subreddit, postID, commentID, direction)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	authorKarma, _ := a.engine.GetUserKarma(comment.Author)
+
+	json.NewEncoder(w).Encode(VoteResponse{
+		PostID:      postID,
+		CommentID:   comment.ID,
+		Direction:   vote.Direction,
+		Upvotes:     comment.Upvotes,
+		Downvotes:   comment.Downvotes,
+		Score:       comment.Upvotes - comment.Downvotes,
+		Author:      comment.Author,
+		AuthorKarma: authorKarma,
+	})
+}
+
+func (a *API) getCommentVote(w http.ResponseWriter, r *http.Request) {
+	subreddit := mux.Vars(r)["sub"]
+	postID, ok := pathInt(r, "id")
+	if !ok {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	commentID, ok := pathInt(r, "cid")
+	if !ok {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	username, ok := a.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	direction, err := a.engine.GetCommentVote(username, subreddit, postID, commentID)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	response := map[string]interface{}{"post_id": postID, "comment_id": commentID, "user": username, "direction": voteDirectionName(direction)}
+	json.NewEncoder(w).Encode(response)
+}
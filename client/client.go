@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"project4/engine"
+	"time"
 )
 
 type Client struct {
@@ -28,6 +29,56 @@ func (c *Client) Register() error {
 	return nil
 }
 
+func (c *Client) CreateSubreddit(name string, kind engine.SubredditType) error {
+	err := c.Engine.CreateSubredditAs(c.Username, name, kind)
+	if err != nil {
+		log.Printf("Error creating subreddit %s: %v", name, err)
+		return err
+	}
+	log.Printf("%s created %s subreddit: %s", c.Username, kind, name)
+	return nil
+}
+
+func (c *Client) AddModerator(subreddit, username string, permissions engine.ModPermission) error {
+	err := c.Engine.AddModerator(c.Username, subreddit, username, permissions)
+	if err != nil {
+		log.Printf("Error adding moderator %s to %s: %v", username, subreddit, err)
+		return err
+	}
+	log.Printf("%s made %s a moderator of %s with %s permissions", c.Username, username, subreddit, permissions)
+	return nil
+}
+
+func (c *Client) BanUser(subreddit, username, reason string, duration time.Duration) error {
+	err := c.Engine.BanUser(c.Username, subreddit, username, reason, duration)
+	if err != nil {
+		log.Printf("Error banning %s from %s: %v", username, subreddit, err)
+		return err
+	}
+	log.Printf("%s banned %s from %s", c.Username, username, subreddit)
+	return nil
+}
+
+func (c *Client) MuteUser(subreddit, username, reason string, duration time.Duration) error {
+	err := c.Engine.MuteUser(c.Username, subreddit, username, reason, duration)
+	if err != nil {
+		log.Printf("Error muting %s in %s: %v", username, subreddit, err)
+		return err
+	}
+	log.Printf("%s muted %s in %s", c.Username, username, subreddit)
+	return nil
+}
+
+func (c *Client) ReportPost(postID int, reason string) error {
+	err := c.Engine.ReportPost(c.Username, postID, reason)
+	if err != nil {
+		log.Printf("Error reporting post %d: %v", postID, err)
+		return err
+	}
+	log.Printf("%s reported post %d: %s", c.Username, postID, reason)
+	return nil
+}
+
 func (c *Client) JoinSubreddit(subreddit string) error {
 	err := c.Engine.JoinSubreddit(c.Username, subreddit)
 	if err != nil {
@@ -57,18 +108,38 @@ func (c *Client) PostInSubreddit(subreddit, content string) (int, error) {
 	return postID, nil
 }
 
-func (c *Client) CommentOnPost(subreddit string, postID int, content string) error {
-	err := c.Engine.CommentOnPost(c.Username, subreddit, postID, content)
+func (c *Client) SubmitPost(subreddit string, draft engine.PostDraft) (int, error) {
+	postID, err := c.Engine.SubmitPost(c.Username, subreddit, draft)
+	if err != nil {
+		log.Printf("Error submitting %s post in subreddit %s: %v", draft.Kind, subreddit, err)
+		return 0, err
+	}
+	log.Printf("%s post created by %s in subreddit %s: ID %d", draft.Kind, c.Username, subreddit, postID)
+	return postID, nil
+}
+
+func (c *Client) CommentOnPost(subreddit string, postID int, content string) (int, error) {
+	commentID, err := c.Engine.CommentOnPost(c.Username, subreddit, postID, content)
 	if err != nil {
 		log.Printf("Error commenting on post ID %d in subreddit %s: %v", postID, subreddit, err)
-		return err
+		return 0, err
 	}
-	log.Printf("%s commented on post ID %d in subreddit %s", c.Username, postID, subreddit)
-	return nil
+	log.Printf("%s commented on post ID %d in subreddit %s: ID %d", c.Username, postID, subreddit, commentID)
+	return commentID, nil
+}
+
+func (c *Client) ReplyToComment(subreddit string, postID, commentID int, content string) (int, error) {
+	replyID, err := c.Engine.ReplyToComment(subreddit, postID, commentID, c.Username, content)
+	if err != nil {
+		log.Printf("Error replying to comment ID %d on post ID %d: %v", commentID, postID, err)
+		return 0, err
+	}
+	log.Printf("%s replied to comment ID %d on post ID %d: ID %d", c.Username, commentID, postID, replyID)
+	return replyID, nil
 }
 
 func (c *Client) UpvotePost(subreddit string, postID int) error {
-	err := c.Engine.UpvotePost(subreddit, postID)
+	err := c.Engine.UpvotePost(c.Username, subreddit, postID)
 	if err != nil {
 		log.Printf("Error upvoting post ID %d in subreddit %s: %v", postID, subreddit, err)
 		return err
@@ -78,7 +149,7 @@ func (c *Client) UpvotePost(subreddit string, postID int) error {
 }
 
 func (c *Client) DownvotePost(subreddit string, postID int) error {
-	err := c.Engine.DownvotePost(subreddit, postID)
+	err := c.Engine.DownvotePost(c.Username, subreddit, postID)
 	if err != nil {
 		log.Printf("Error downvoting post ID %d in subreddit %s: %v", postID, subreddit, err)
 		return err
@@ -87,6 +158,26 @@ func (c *Client) DownvotePost(subreddit string, postID int) error {
 	return nil
 }
 
+func (c *Client) GetFeed(subreddit string, sortBy engine.FeedSort, limit int) ([]engine.Post, error) {
+	page, err := c.Engine.QueryFeed(subreddit, engine.FeedOptions{Sort: sortBy, Window: engine.WindowAll, Limit: limit, Viewer: c.Username})
+	if err != nil {
+		log.Printf("Error fetching %s feed of %s: %v", sortBy, subreddit, err)
+		return nil, err
+	}
+	log.Printf("%s fetched %d %s posts from %s", c.Username, len(page.Posts), sortBy, subreddit)
+	return page.Posts, nil
+}
+
+func (c *Client) GetHomeFeed(sortBy engine.FeedSort, cursor string, limit int) (engine.FeedPage, error) {
+	page, err := c.Engine.GetHomeFeed(c.Username, sortBy, cursor, limit)
+	if err != nil {
+		log.Printf("Error fetching home feed for %s: %v", c.Username, err)
+		return page, err
+	}
+	log.Printf("%s fetched %d of %d home feed posts", c.Username, len(page.Posts), page.Total)
+	return page, nil
+}
+
 func (c *Client) DisplayKarma() {
 	karma, err := c.Engine.GetUserKarma(c.Username)
 	if err != nil {
@@ -97,7 +188,7 @@ func (c *Client) DisplayKarma() {
 }
 
 func (c *Client) SendMessage(receiver, content string) error {
-	err := c.Engine.SendMessage(c.Username, receiver, content)
+	_, err := c.Engine.SendMessage(c.Username, receiver, content)
 	if err != nil {
 		log.Printf("Error sending message to %s: %v", receiver, err)
 		return err
@@ -5,13 +5,16 @@ import (
 	"log"
 	"math/rand"
 	"project4/engine"
+	"time"
 )
 
+var PostHistory = 365 * 24 * time.Hour
+
 func SimulateClients(e *engine.Engine, userCount, subredditCount, postCount, messageCount int) {
 
 	users := createUsers(e, userCount)
 
-	subreddits := createSubreddits(e, subredditCount)
+	subreddits := createSubreddits(e, users, subredditCount)
 
 	GenerateZipfSubreddits(e, subreddits, users)
 
@@ -45,11 +48,16 @@ func createUsers(e *engine.Engine, count int) []string {
 	return users
 }
 
-func createSubreddits(e *engine.Engine, count int) []string {
+func createSubreddits(e *engine.Engine, users []string, count int) []string {
 	subreddits := []string{}
 	for i := 0; i < count; i++ {
 		name := fmt.Sprintf("subreddit_%d", i+1)
-		err := e.CreateSubreddit(name)
+		var err error
+		if len(users) > 0 {
+			err = e.CreateSubredditAs(users[rand.Intn(len(users))], name, engine.TypePublic)
+		} else {
+			err = e.CreateSubreddit(name)
+		}
 		if err != nil {
 			log.Printf("Error creating subreddit %s: %v", name, err)
 		} else {
@@ -60,12 +68,30 @@ func createSubreddits(e *engine.Engine, count int) []string {
 	return subreddits
 }
 
+var simulatedFlairs = []string{"", "Discussion", "Question", "News"}
+
 func simulatePosts(e *engine.Engine, users []string, subreddits []string, count int) {
+	now := time.Now()
 	for i := 0; i < count; i++ {
 		user := users[rand.Intn(len(users))]
 		subreddit := subreddits[rand.Intn(len(subreddits))]
-		content := fmt.Sprintf("Post #%d by %s in %s", i+1, user, subreddit)
-		_, err := e.PostInSubreddit(user, subreddit, content)
+		draft := engine.PostDraft{
+			Title:   fmt.Sprintf("Post #%d by %s in %s", i+1, user, subreddit),
+			Kind:    engine.KindText,
+			Content: fmt.Sprintf("Simulated content for post #%d", i+1),
+			NSFW:    rand.Intn(10) == 0,
+			Spoiler: rand.Intn(20) == 0,
+			Flair:   simulatedFlairs[rand.Intn(len(simulatedFlairs))],
+		}
+		if rand.Intn(4) == 0 {
+			draft.Kind = engine.KindLink
+			draft.URL = fmt.Sprintf("https://example.com/%s/%d", subreddit, i+1)
+		}
+		postedAt := now
+		if PostHistory > 0 {
+			postedAt = now.Add(-time.Duration(rand.Int63n(int64(PostHistory))))
+		}
+		_, err := e.SubmitPostAt(user, subreddit, draft, postedAt)
 		if err != nil {
 			log.Printf("Error posting: %v", err)
 		}
@@ -75,14 +101,14 @@ func simulatePosts(e *engine.Engine, users []string, subreddits []string, count
 
 func simulateComments(e *engine.Engine, users []string, subreddits []string) {
 	for _, subreddit := range subreddits {
-		posts, err := e.GetFeed(subreddit, "time", 10)
+		posts, err := e.GetFeed(subreddit, engine.SortNew, 10)
 		if err != nil || len(posts) == 0 {
 			continue
 		}
 		for _, post := range posts {
 			user := users[rand.Intn(len(users))]
 			commentContent := fmt.Sprintf("Comment by %s on post ID %d", user, post.ID)
-			err := e.CommentOnPost(user, subreddit, post.ID, commentContent)
+			_, err := e.CommentOnPost(user, subreddit, post.ID, commentContent)
 			if err != nil {
 				log.Printf("Error commenting: %v", err)
 			}
@@ -99,7 +125,7 @@ func simulateMessaging(e *engine.Engine, users []string, count int) {
 			continue
 		}
 		content := fmt.Sprintf("Message #%d from %s to %s", i+1, sender, receiver)
-		err := e.SendMessage(sender, receiver, content)
+		_, err := e.SendMessage(sender, receiver, content)
 		if err != nil {
 			log.Printf("Error sending message: %v", err)
 		}
@@ -4,25 +4,97 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"project4/signing"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var BaseURL = "http://localhost:8080"
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = map[string]string{}
 )
 
 type User struct {
 	Username  string `json:"username"`
 	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
 }
 
 type Post struct {
-	User      string `json:"user"`
+	Subreddit string `json:"subreddit"`
+	Title     string `json:"title"`
+	Kind      string `json:"kind,omitempty"`
 	Content   string `json:"content"`
+	URL       string `json:"url,omitempty"`
+	NSFW      bool   `json:"nsfw,omitempty"`
+	Spoiler   bool   `json:"spoiler,omitempty"`
+	Flair     string `json:"flair,omitempty"`
+}
+
+type LoginRequest struct {
+	Username  string `json:"username"`
+	Challenge string `json:"challenge"`
 	Signature string `json:"signature"`
 }
 
+type Comment struct {
+	Content string `json:"content"`
+}
+
+type Vote struct {
+	Direction string `json:"direction"`
+}
+
+type SubredditRequest struct {
+	Type string `json:"type"`
+}
+
+type SubredditUser struct {
+	Username string `json:"username"`
+}
+
+type ModeratorRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type SubredditSettings struct {
+	Description string `json:"description"`
+}
+
+type Restriction struct {
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration,omitempty"`
+}
+
+type Report struct {
+	Reason string `json:"reason"`
+}
+
+type ModerationAction struct {
+	Action string `json:"action"`
+}
+
+type FilterWords struct {
+	Words []string `json:"words"`
+}
+
+type DirectMessage struct {
+	Receiver string `json:"receiver"`
+	Content  string `json:"content"`
+}
+
 func GenerateKeys() (*rsa.PrivateKey, string) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
@@ -30,25 +102,176 @@ func GenerateKeys() (*rsa.PrivateKey, string) {
 		return nil, ""
 	}
 
-	publicKeyBytes := base64.StdEncoding.EncodeToString(privateKey.PublicKey.N.Bytes())
-	return privateKey, publicKeyBytes
+	publicKey, err := EncodePublicKey(&privateKey.PublicKey)
+	if err != nil {
+		fmt.Println("Error encoding public key:", err)
+		return nil, ""
+	}
+	return privateKey, publicKey
+}
+
+func EncodePublicKey(publicKey *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+func EncodeLegacyPublicKey(publicKey *rsa.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(publicKey.N.Bytes())
 }
 
 func SignMessage(privateKey *rsa.PrivateKey, message string) string {
-	hash := sha256.Sum256([]byte(message))
-	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, 0, hash[:])
+	signature, err := signing.Sign(privateKey, message)
 	if err != nil {
 		fmt.Println("Error signing message:", err)
 		return ""
 	}
-	return base64.StdEncoding.EncodeToString(signature)
+	return signature
+}
+
+func SignRequest(req *http.Request, body []byte, username string, privateKey *rsa.PrivateKey) error {
+	nonce, err := signing.NewNonce()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	message := signing.CanonicalRequest(req.Method, req.URL.RequestURI(), body, timestamp, nonce)
+
+	signature, err := signing.Sign(privateKey, message)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(signing.HeaderUser, username)
+	req.Header.Set(signing.HeaderTimestamp, timestamp)
+	req.Header.Set(signing.HeaderNonce, nonce)
+	req.Header.Set(signing.HeaderSignature, signature)
+	return nil
+}
+
+func NewSignedRequest(method, url string, payload interface{}, username string, privateKey *rsa.PrivateKey) (*http.Request, error) {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := SignRequest(req, body, username, privateKey); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func NewSessionRequest(method, url string, payload interface{}, token string) (*http.Request, error) {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
+
+func sessionToken(username string) (string, bool) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	token, exists := sessions[username]
+	return token, exists
+}
+
+func sendSigned(method, url string, payload interface{}, username string, privateKey *rsa.PrivateKey, label string) {
+	var req *http.Request
+	var err error
+	if token, exists := sessionToken(username); exists {
+		req, err = NewSessionRequest(method, url, payload, token)
+	} else {
+		req, err = NewSignedRequest(method, url, payload, username, privateKey)
+	}
+	if err != nil {
+		fmt.Printf("Error in %s: %v\n", label, err)
+		return
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Printf("Error in %s: %v\n", label, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	fmt.Printf("%s Response: %s\n", label, string(body))
 }
 
-func RegisterUser(username, publicKey string) {
-	user := User{Username: username, PublicKey: publicKey}
+func getJSON(url string, label string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("Error in %s: %v\n", label, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	fmt.Printf("%s: %s\n", label, string(body))
+}
+
+func NewRegistration(username string, privateKey *rsa.PrivateKey) (User, error) {
+	publicKey, err := EncodePublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return User{}, err
+	}
+	signature, err := signing.Sign(privateKey, signing.RegistrationMessage(username))
+	if err != nil {
+		return User{}, err
+	}
+	return User{Username: username, PublicKey: publicKey, Signature: signature}, nil
+}
+
+func NewKeyRotation(username string, currentKey, newKey *rsa.PrivateKey) (User, error) {
+	publicKey, err := EncodePublicKey(&newKey.PublicKey)
+	if err != nil {
+		return User{}, err
+	}
+	fingerprint, err := signing.KeyFingerprint(&currentKey.PublicKey)
+	if err != nil {
+		return User{}, err
+	}
+	signature, err := signing.Sign(newKey, signing.RotationMessage(username, fingerprint))
+	if err != nil {
+		return User{}, err
+	}
+	return User{Username: username, PublicKey: publicKey, Signature: signature}, nil
+}
+
+func RegisterUser(username string, privateKey *rsa.PrivateKey) {
+	user, err := NewRegistration(username, privateKey)
+	if err != nil {
+		fmt.Println("Error registering user:", err)
+		return
+	}
 	payload, _ := json.Marshal(user)
 
-	resp, err := http.Post("http://localhost:8080/register", "application/json", bytes.NewBuffer(payload))
+	resp, err := http.Post(BaseURL+"/register", "application/json", bytes.NewBuffer(payload))
 	if err != nil {
 		fmt.Println("Error registering user:", err)
 		return
@@ -59,24 +282,114 @@ func RegisterUser(username, publicKey string) {
 	fmt.Println("Register Response:", string(body))
 }
 
-func CreatePost(username, content string, privateKey *rsa.PrivateKey) {
-	signature := SignMessage(privateKey, content)
-	post := Post{User: username, Content: content, Signature: signature}
+func RotateKey(username string, currentKey, newKey *rsa.PrivateKey) {
+	user, err := NewKeyRotation(username, currentKey, newKey)
+	if err != nil {
+		fmt.Println("Error rotating key:", err)
+		return
+	}
+	url := fmt.Sprintf("%s/user/%s/publickey", BaseURL, username)
+	req, err := NewSignedRequest("PUT", url, user, username, currentKey)
+	if err != nil {
+		fmt.Println("Error rotating key:", err)
+		return
+	}
 
-	payload, _ := json.Marshal(post)
-	resp, err := http.Post("http://localhost:8080/post", "application/json", bytes.NewBuffer(payload))
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		fmt.Println("Error creating post:", err)
+		fmt.Println("Error rotating key:", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	body, _ := ioutil.ReadAll(resp.Body)
-	fmt.Println("Create Post Response:", string(body))
+	fmt.Println("Rotate Key Response:", string(body))
+}
+
+func Login(username string, privateKey *rsa.PrivateKey) (string, error) {
+	payload, _ := json.Marshal(User{Username: username})
+	resp, err := http.Post(BaseURL+"/login/challenge", "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("login challenge for %s failed: %s", username, string(body))
+	}
+
+	var challenge struct {
+		Challenge string `json:"challenge"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&challenge); err != nil {
+		return "", err
+	}
+
+	signature, err := signing.Sign(privateKey, signing.LoginMessage(username, challenge.Challenge))
+	if err != nil {
+		return "", err
+	}
+	payload, _ = json.Marshal(LoginRequest{Username: username, Challenge: challenge.Challenge, Signature: signature})
+	resp, err = http.Post(BaseURL+"/login", "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("login for %s failed: %s", username, string(body))
+	}
+
+	var session struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return "", err
+	}
+
+	sessionsMu.Lock()
+	sessions[username] = session.Token
+	sessionsMu.Unlock()
+	fmt.Printf("Login Response: logged in as %s\n", username)
+	return session.Token, nil
+}
+
+func Logout(username string) {
+	token, exists := sessionToken(username)
+	if !exists {
+		return
+	}
+
+	sessionsMu.Lock()
+	delete(sessions, username)
+	sessionsMu.Unlock()
+
+	req, err := NewSessionRequest("POST", BaseURL+"/logout", nil, token)
+	if err != nil {
+		fmt.Println("Error logging out:", err)
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Println("Error logging out:", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	fmt.Println("Logout Response:", string(body))
+}
+
+func CreatePost(username, subreddit, content string, privateKey *rsa.PrivateKey) {
+	SubmitPost(username, Post{Subreddit: subreddit, Content: content}, privateKey)
+}
+
+func SubmitPost(username string, post Post, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", BaseURL+"/post", post, username, privateKey, "Create Post")
 }
 
 func FetchPosts() {
-	resp, err := http.Get("http://localhost:8080/posts")
+	resp, err := http.Get(BaseURL + "/posts")
 	if err != nil {
 		fmt.Println("Error fetching posts:", err)
 		return
@@ -87,8 +400,29 @@ func FetchPosts() {
 	fmt.Println("All Posts:", string(body))
 }
 
+func FetchPost(postID int) {
+	getJSON(fmt.Sprintf("%s/posts/%d", BaseURL, postID), "Post")
+}
+
+func FetchComment(commentID int) {
+	getJSON(fmt.Sprintf("%s/comments/%d", BaseURL, commentID), "Comment")
+}
+
+func FetchCommentTree(postID int, sort string, depth, limit int) {
+	getJSON(fmt.Sprintf("%s/posts/%d/comments?sort=%s&depth=%d&limit=%d", BaseURL, postID, sort, depth, limit), "Comment Tree")
+}
+
+func FetchFeed(subreddit, sort, window, after string, limit int) {
+	getJSON(fmt.Sprintf("%s/posts?subreddit=%s&sort=%s&t=%s&limit=%d&after=%s", BaseURL, subreddit, sort, window, limit, after), "Feed")
+}
+
+func FetchFilteredFeed(subreddit, flair, nsfw string, limit int) {
+	query := url.Values{"subreddit": {subreddit}, "flair": {flair}, "nsfw": {nsfw}, "limit": {strconv.Itoa(limit)}}
+	getJSON(BaseURL+"/posts?"+query.Encode(), "Filtered Feed")
+}
+
 func FetchUserPublicKey(username string) {
-	url := fmt.Sprintf("http://localhost:8080/user/%s/publickey", username)
+	url := fmt.Sprintf("%s/user/%s/publickey", BaseURL, username)
 	resp, err := http.Get(url)
 	if err != nil {
 		fmt.Println("Error fetching public key:", err)
@@ -99,3 +433,175 @@ func FetchUserPublicKey(username string) {
 	body, _ := ioutil.ReadAll(resp.Body)
 	fmt.Println("User Public Key:", string(body))
 }
+
+func CreateSubreddit(username, subreddit string, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", fmt.Sprintf("%s/r/%s", BaseURL, subreddit), nil, username, privateKey, "Create Subreddit")
+}
+
+func CreateSubredditOfType(username, subreddit, kind string, privateKey *rsa.PrivateKey) {
+	request := SubredditRequest{Type: kind}
+	sendSigned("POST", fmt.Sprintf("%s/r/%s", BaseURL, subreddit), request, username, privateKey, "Create Subreddit")
+}
+
+func ApproveUser(moderator, subreddit, username string, privateKey *rsa.PrivateKey) {
+	request := SubredditUser{Username: username}
+	sendSigned("POST", fmt.Sprintf("%s/r/%s/approved", BaseURL, subreddit), request, moderator, privateKey, "Approve User")
+}
+
+func InviteUser(moderator, subreddit, username string, privateKey *rsa.PrivateKey) {
+	request := SubredditUser{Username: username}
+	sendSigned("POST", fmt.Sprintf("%s/r/%s/invites", BaseURL, subreddit), request, moderator, privateKey, "Invite User")
+}
+
+func FetchFeedAs(username, subreddit string, limit int, privateKey *rsa.PrivateKey) {
+	query := url.Values{"subreddit": {subreddit}, "limit": {strconv.Itoa(limit)}}
+	sendSigned("GET", BaseURL+"/posts?"+query.Encode(), nil, username, privateKey, "Feed")
+}
+
+func UpdateSubreddit(username, subreddit, description string, privateKey *rsa.PrivateKey) {
+	settings := SubredditSettings{Description: description}
+	sendSigned("PATCH", fmt.Sprintf("%s/r/%s", BaseURL, subreddit), settings, username, privateKey, "Update Subreddit")
+}
+
+func AddModerator(username, subreddit, moderator string, permissions []string, privateKey *rsa.PrivateKey) {
+	request := ModeratorRequest{Permissions: permissions}
+	sendSigned("PUT", fmt.Sprintf("%s/r/%s/moderators/%s", BaseURL, subreddit, moderator), request, username, privateKey, "Add Moderator")
+}
+
+func RemoveModerator(username, subreddit, moderator string, privateKey *rsa.PrivateKey) {
+	sendSigned("DELETE", fmt.Sprintf("%s/r/%s/moderators/%s", BaseURL, subreddit, moderator), nil, username, privateKey, "Remove Moderator")
+}
+
+func FetchModerators(subreddit string) {
+	getJSON(fmt.Sprintf("%s/r/%s/moderators", BaseURL, subreddit), "Moderators")
+}
+
+func BanUser(moderator, subreddit, username, reason, duration string, privateKey *rsa.PrivateKey) {
+	request := Restriction{Username: username, Reason: reason, Duration: duration}
+	sendSigned("POST", fmt.Sprintf("%s/r/%s/bans", BaseURL, subreddit), request, moderator, privateKey, "Ban User")
+}
+
+func UnbanUser(moderator, subreddit, username string, privateKey *rsa.PrivateKey) {
+	sendSigned("DELETE", fmt.Sprintf("%s/r/%s/bans/%s", BaseURL, subreddit, username), nil, moderator, privateKey, "Unban User")
+}
+
+func MuteUser(moderator, subreddit, username, reason, duration string, privateKey *rsa.PrivateKey) {
+	request := Restriction{Username: username, Reason: reason, Duration: duration}
+	sendSigned("POST", fmt.Sprintf("%s/r/%s/mutes", BaseURL, subreddit), request, moderator, privateKey, "Mute User")
+}
+
+func UnmuteUser(moderator, subreddit, username string, privateKey *rsa.PrivateKey) {
+	sendSigned("DELETE", fmt.Sprintf("%s/r/%s/mutes/%s", BaseURL, subreddit, username), nil, moderator, privateKey, "Unmute User")
+}
+
+func FetchBans(moderator, subreddit string, privateKey *rsa.PrivateKey) {
+	sendSigned("GET", fmt.Sprintf("%s/r/%s/bans", BaseURL, subreddit), nil, moderator, privateKey, "Bans")
+}
+
+func ReportPost(username string, postID int, reason string, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", fmt.Sprintf("%s/posts/%d/report", BaseURL, postID), Report{Reason: reason}, username, privateKey, "Report Post")
+}
+
+func ReportComment(username string, commentID int, reason string, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", fmt.Sprintf("%s/comments/%d/report", BaseURL, commentID), Report{Reason: reason}, username, privateKey, "Report Comment")
+}
+
+func ModeratePost(moderator string, postID int, action string, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", fmt.Sprintf("%s/posts/%d/moderate", BaseURL, postID), ModerationAction{Action: action}, moderator, privateKey, "Moderate Post")
+}
+
+func ModerateComment(moderator string, commentID int, action string, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", fmt.Sprintf("%s/comments/%d/moderate", BaseURL, commentID), ModerationAction{Action: action}, moderator, privateKey, "Moderate Comment")
+}
+
+func FetchModQueue(moderator, subreddit string, privateKey *rsa.PrivateKey) {
+	sendSigned("GET", fmt.Sprintf("%s/r/%s/modqueue", BaseURL, subreddit), nil, moderator, privateKey, "Mod Queue")
+}
+
+func SetFilterWords(moderator, subreddit string, words []string, privateKey *rsa.PrivateKey) {
+	sendSigned("PUT", fmt.Sprintf("%s/r/%s/filter", BaseURL, subreddit), FilterWords{Words: words}, moderator, privateKey, "Set Filter Words")
+}
+
+func JoinSubreddit(username, subreddit string, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", fmt.Sprintf("%s/r/%s/join", BaseURL, subreddit), nil, username, privateKey, "Join Subreddit")
+}
+
+func LeaveSubreddit(username, subreddit string, privateKey *rsa.PrivateKey) {
+	sendSigned("POST", fmt.Sprintf("%s/r/%s/leave", BaseURL, subreddit), nil, username, privateKey, "Leave Subreddit")
+}
+
+func FetchSubreddits() {
+	getJSON(BaseURL+"/r", "All Subreddits")
+}
+
+func FetchSubreddit(subreddit string) {
+	getJSON(fmt.Sprintf("%s/r/%s", BaseURL, subreddit), "Subreddit")
+}
+
+func FetchSubredditMembers(subreddit string) {
+	getJSON(fmt.Sprintf("%s/r/%s/members", BaseURL, subreddit), "Subreddit Members")
+}
+
+func CommentOnPost(username, subreddit string, postID int, content string, privateKey *rsa.PrivateKey) {
+	url := fmt.Sprintf("%s/r/%s/posts/%d/comments", BaseURL, subreddit, postID)
+	sendSigned("POST", url, Comment{Content: content}, username, privateKey, "Comment")
+}
+
+func ReplyToComment(username, subreddit string, postID, commentID int, content string, privateKey *rsa.PrivateKey) {
+	url := fmt.Sprintf("%s/r/%s/posts/%d/comments/%d/replies", BaseURL, subreddit, postID, commentID)
+	sendSigned("POST", url, Comment{Content: content}, username, privateKey, "Reply")
+}
+
+func EditPost(username string, postID int, content string, privateKey *rsa.PrivateKey) {
+	sendSigned("PUT", fmt.Sprintf("%s/posts/%d", BaseURL, postID), Comment{Content: content}, username, privateKey, "Edit Post")
+}
+
+func DeletePost(username string, postID int, privateKey *rsa.PrivateKey) {
+	sendSigned("DELETE", fmt.Sprintf("%s/posts/%d", BaseURL, postID), nil, username, privateKey, "Delete Post")
+}
+
+func EditComment(username string, commentID int, content string, privateKey *rsa.PrivateKey) {
+	sendSigned("PUT", fmt.Sprintf("%s/comments/%d", BaseURL, commentID), Comment{Content: content}, username, privateKey, "Edit Comment")
+}
+
+func DeleteComment(username string, commentID int, privateKey *rsa.PrivateKey) {
+	sendSigned("DELETE", fmt.Sprintf("%s/comments/%d", BaseURL, commentID), nil, username, privateKey, "Delete Comment")
+}
+
+func FetchComments(subreddit string, postID int) {
+	getJSON(fmt.Sprintf("%s/r/%s/posts/%d/comments", BaseURL, subreddit, postID), "Comments")
+}
+
+func VotePost(username, subreddit string, postID int, direction string, privateKey *rsa.PrivateKey) {
+	url := fmt.Sprintf("%s/r/%s/posts/%d/vote", BaseURL, subreddit, postID)
+	sendSigned("POST", url, Vote{Direction: direction}, username, privateKey, "Vote")
+}
+
+func VoteComment(username, subreddit string, postID, commentID int, direction string, privateKey *rsa.PrivateKey) {
+	url := fmt.Sprintf("%s/r/%s/posts/%d/comments/%d/vote", BaseURL, subreddit, postID, commentID)
+	sendSigned("POST", url, Vote{Direction: direction}, username, privateKey, "Comment Vote")
+}
+
+func FetchMyVote(username, subreddit string, postID int, privateKey *rsa.PrivateKey) {
+	url := fmt.Sprintf("%s/r/%s/posts/%d/vote", BaseURL, subreddit, postID)
+	sendSigned("GET", url, nil, username, privateKey, "My Vote")
+}
+
+func SendMessage(sender, receiver, content string, privateKey *rsa.PrivateKey) {
+	message := DirectMessage{Receiver: receiver, Content: content}
+	sendSigned("POST", BaseURL+"/messages", message, sender, privateKey, "Send Message")
+}
+
+func ReplyToMessage(sender string, messageID int, content string, privateKey *rsa.PrivateKey) {
+	url := fmt.Sprintf("%s/messages/%d/reply", BaseURL, messageID)
+	sendSigned("POST", url, DirectMessage{Content: content}, sender, privateKey, "Reply Message")
+}
+
+func FetchInbox(username string, privateKey *rsa.PrivateKey) {
+	sendSigned("GET", fmt.Sprintf("%s/users/%s/inbox", BaseURL, username), nil, username, privateKey, "Inbox")
+}
+
+func FetchHomeFeed(username, sort, after string, limit int, privateKey *rsa.PrivateKey) {
+	url := fmt.Sprintf("%s/users/%s/home?sort=%s&limit=%d&after=%s", BaseURL, username, sort, limit, after)
+	sendSigned("GET", url, nil, username, privateKey, "Home Feed")
+}
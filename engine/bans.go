@@ -0,0 +1,210 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type Restriction struct {
+	Username  string
+	Moderator string
+	Reason    string
+	Created   time.Time
+	Expires   time.Time
+}
+
+type BannedError struct {
+	Subreddit string
+	Username  string
+	Reason    string
+	Expires   time.Time
+}
+
+type MutedError struct {
+	Subreddit string
+	Username  string
+	Reason    string
+	Expires   time.Time
+}
+
+func (r Restriction) Permanent() bool {
+	return r.Expires.IsZero()
+}
+
+func (r Restriction) activeAt(now time.Time) bool {
+	return r.Permanent() || now.Before(r.Expires)
+}
+
+func restrictionSuffix(reason string, expires time.Time) string {
+	suffix := ""
+	if reason != "" {
+		suffix += fmt.Sprintf(" (%s)", reason)
+	}
+	if expires.IsZero() {
+		return suffix + " permanently"
+	}
+	return suffix + " until " + expires.Format(time.RFC3339)
+}
+
+func (err *BannedError) Error() string {
+	return fmt.Sprintf("%s: %s from %s%s", ErrBanned, err.Username, err.Subreddit, restrictionSuffix(err.Reason, err.Expires))
+}
+
+func (err *BannedError) Unwrap() error {
+	return ErrBanned
+}
+
+func (err *MutedError) Error() string {
+	return fmt.Sprintf("%s: %s in %s%s", ErrMuted, err.Username, err.Subreddit, restrictionSuffix(err.Reason, err.Expires))
+}
+
+func (err *MutedError) Unwrap() error {
+	return ErrMuted
+}
+
+func activeRestriction(restrictions map[string]Restriction, username string, now time.Time) (Restriction, bool) {
+	restriction, exists := restrictions[username]
+	if !exists {
+		return Restriction{}, false
+	}
+	if !restriction.activeAt(now) {
+		delete(restrictions, username)
+		return Restriction{}, false
+	}
+	return restriction, true
+}
+
+func (e *Engine) checkBanned(sub *Subreddit, username string) error {
+	ban, banned := activeRestriction(sub.Bans, username, time.Now())
+	if !banned {
+		return nil
+	}
+	return &BannedError{Subreddit: sub.Name, Username: username, Reason: ban.Reason, Expires: ban.Expires}
+}
+
+func (e *Engine) checkCanContribute(sub *Subreddit, username string) error {
+	if err := e.checkBanned(sub, username); err != nil {
+		return err
+	}
+	mute, muted := activeRestriction(sub.Mutes, username, time.Now())
+	if !muted {
+		return nil
+	}
+	return &MutedError{Subreddit: sub.Name, Username: username, Reason: mute.Reason, Expires: mute.Expires}
+}
+
+func (e *Engine) restrict(moderator, subreddit, username, reason string, duration time.Duration, pick func(*Subreddit) map[string]Restriction) error {
+	if err := e.requireConnected(moderator); err != nil {
+		return err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, moderator, PermUsers); err != nil {
+		return err
+	}
+	if _, exists := e.Users[username]; !exists {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	if sub.permissions(username) != PermNone {
+		return fmt.Errorf("%w: %s moderates %s", ErrPermissionDenied, username, subreddit)
+	}
+	if duration < 0 {
+		return fmt.Errorf("%w: negative duration %s", ErrInvalidDuration, duration)
+	}
+
+	now := time.Now()
+	restriction := Restriction{Username: username, Moderator: moderator, Reason: reason, Created: now}
+	if duration > 0 {
+		restriction.Expires = now.Add(duration)
+	}
+	pick(sub)[username] = restriction
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) lift(moderator, subreddit, username string, pick func(*Subreddit) map[string]Restriction, missing error) error {
+	if err := e.requireConnected(moderator); err != nil {
+		return err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, moderator, PermUsers); err != nil {
+		return err
+	}
+	if _, active := activeRestriction(pick(sub), username, time.Now()); !active {
+		return fmt.Errorf("%w: %s in %s", missing, username, subreddit)
+	}
+
+	delete(pick(sub), username)
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) listRestrictions(moderator, subreddit string, pick func(*Subreddit) map[string]Restriction) ([]Restriction, error) {
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, moderator, PermUsers); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	restrictions := []Restriction{}
+	for username := range pick(sub) {
+		if restriction, active := activeRestriction(pick(sub), username, now); active {
+			restrictions = append(restrictions, restriction)
+		}
+	}
+	sort.Slice(restrictions, func(i, j int) bool { return restrictions[i].Username < restrictions[j].Username })
+	return restrictions, nil
+}
+
+func bans(sub *Subreddit) map[string]Restriction {
+	return sub.Bans
+}
+
+func mutes(sub *Subreddit) map[string]Restriction {
+	return sub.Mutes
+}
+
+func (e *Engine) BanUser(moderator, subreddit, username, reason string, duration time.Duration) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.restrict(moderator, subreddit, username, reason, duration, bans)
+}
+
+func (e *Engine) UnbanUser(moderator, subreddit, username string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lift(moderator, subreddit, username, bans, ErrNotBanned)
+}
+
+func (e *Engine) ListBans(moderator, subreddit string) ([]Restriction, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.listRestrictions(moderator, subreddit, bans)
+}
+
+func (e *Engine) MuteUser(moderator, subreddit, username, reason string, duration time.Duration) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.restrict(moderator, subreddit, username, reason, duration, mutes)
+}
+
+func (e *Engine) UnmuteUser(moderator, subreddit, username string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lift(moderator, subreddit, username, mutes, ErrNotMuted)
+}
+
+func (e *Engine) ListMutes(moderator, subreddit string) ([]Restriction, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.listRestrictions(moderator, subreddit, mutes)
+}
@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type CommentSort int
+
+const (
+	CommentBest CommentSort = iota
+	CommentTop
+	CommentNew
+	CommentControversial
+)
+
+const wilsonZ = 1.281551565545
+
+var commentSortNames = map[CommentSort]string{
+	CommentBest:          "best",
+	CommentTop:           "top",
+	CommentNew:           "new",
+	CommentControversial: "controversial",
+}
+
+type CommentNode struct {
+	ID        int
+	PostID    int
+	ParentID  int
+	Author    string
+	Content   string
+	Upvotes   int
+	Downvotes int
+	Timestamp time.Time
+	Edited    time.Time
+	Deleted   bool
+	Removed   bool
+	Depth     int
+	Replies   []*CommentNode
+	More      *MoreChildren
+}
+
+type MoreChildren struct {
+	ParentID int
+	Count    int
+	Children []int
+}
+
+type CommentTree struct {
+	PostID   int
+	Comments []*CommentNode
+	More     *MoreChildren
+}
+
+func (s CommentSort) String() string {
+	if name, ok := commentSortNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("CommentSort(%d)", int(s))
+}
+
+func ParseCommentSort(name string) (CommentSort, error) {
+	for sort, sortName := range commentSortNames {
+		if strings.EqualFold(name, sortName) {
+			return sort, nil
+		}
+	}
+	return CommentBest, fmt.Errorf("%w: %q", ErrInvalidSort, name)
+}
+
+func BestScore(comment Comment) float64 {
+	n := float64(comment.Upvotes + comment.Downvotes)
+	if n == 0 {
+		return 0
+	}
+	p := float64(comment.Upvotes) / n
+	z2 := wilsonZ * wilsonZ
+	return (p + z2/(2*n) - wilsonZ*math.Sqrt((p*(1-p)+z2/(4*n))/n)) / (1 + z2/n)
+}
+
+func sortComments(comments []*Comment, by CommentSort) ([]*Comment, error) {
+	var key func(*Comment) float64
+	switch by {
+	case CommentBest:
+		key = func(comment *Comment) float64 { return BestScore(*comment) }
+	case CommentTop:
+		key = func(comment *Comment) float64 { return float64(comment.Upvotes - comment.Downvotes) }
+	case CommentNew:
+		key = func(comment *Comment) float64 { return float64(comment.Timestamp.UnixNano()) }
+	case CommentControversial:
+		key = func(comment *Comment) float64 { return controversy(comment.Upvotes, comment.Downvotes) }
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSort, by)
+	}
+
+	sorted := append([]*Comment{}, comments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, kj := key(sorted[i]), key(sorted[j])
+		if ki != kj {
+			return ki > kj
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted, nil
+}
+
+func countDescendants(comments []*Comment) int {
+	count := len(comments)
+	for _, comment := range comments {
+		count += countDescendants(comment.Replies)
+	}
+	return count
+}
+
+func newMoreChildren(parentID int, comments []*Comment) *MoreChildren {
+	more := &MoreChildren{ParentID: parentID, Count: countDescendants(comments)}
+	for _, comment := range comments {
+		more.Children = append(more.Children, comment.ID)
+	}
+	return more
+}
+
+func buildCommentNodes(comments []*Comment, parentID, depth int, by CommentSort, maxDepth int, budget *int, moderator bool) ([]*CommentNode, *MoreChildren, error) {
+	sorted, err := sortComments(comments, by)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodes := []*CommentNode{}
+	for i, comment := range sorted {
+		if *budget == 0 {
+			return nodes, newMoreChildren(parentID, sorted[i:]), nil
+		}
+		if *budget > 0 {
+			*budget--
+		}
+
+		node := &CommentNode{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			ParentID:  comment.ParentID,
+			Author:    comment.Author,
+			Content:   comment.Content,
+			Upvotes:   comment.Upvotes,
+			Downvotes: comment.Downvotes,
+			Timestamp: comment.Timestamp,
+			Edited:    comment.Edited,
+			Deleted:   comment.Deleted,
+			Removed:   comment.Removed,
+			Depth:     depth,
+			Replies:   []*CommentNode{},
+		}
+		if comment.Removed && !moderator {
+			node.Author = RemovedMarker
+			node.Content = RemovedMarker
+		}
+		if len(comment.Replies) > 0 {
+			if maxDepth > 0 && depth+1 >= maxDepth {
+				node.More = newMoreChildren(comment.ID, comment.Replies)
+			} else if node.Replies, node.More, err = buildCommentNodes(comment.Replies, comment.ID, depth+1, by, maxDepth, budget, moderator); err != nil {
+				return nil, nil, err
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil, nil
+}
+
+func commentBudget(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
+
+func (e *Engine) GetCommentTree(postID int, sortBy CommentSort, maxDepth, limit int) (CommentTree, error) {
+	return e.GetCommentTreeAs("", postID, sortBy, maxDepth, limit)
+}
+
+func (e *Engine) GetCommentTreeAs(viewer string, postID int, sortBy CommentSort, maxDepth, limit int) (CommentTree, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	post, moderator, err := e.viewPost(viewer, postID)
+	if err != nil {
+		return CommentTree{}, err
+	}
+
+	budget := commentBudget(limit)
+	comments, more, err := buildCommentNodes(post.Comments, 0, 0, sortBy, maxDepth, &budget, moderator)
+	if err != nil {
+		return CommentTree{}, err
+	}
+	return CommentTree{PostID: postID, Comments: comments, More: more}, nil
+}
+
+func (e *Engine) GetMoreChildren(postID int, children []int, sortBy CommentSort, maxDepth, limit int) (CommentTree, error) {
+	return e.GetMoreChildrenAs("", postID, children, sortBy, maxDepth, limit)
+}
+
+func (e *Engine) GetMoreChildrenAs(viewer string, postID int, children []int, sortBy CommentSort, maxDepth, limit int) (CommentTree, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, moderator, err := e.viewPost(viewer, postID)
+	if err != nil {
+		return CommentTree{}, err
+	}
+
+	comments := make([]*Comment, 0, len(children))
+	for _, id := range children {
+		comment, exists := e.comments[id]
+		if !exists || comment.PostID != postID {
+			return CommentTree{}, fmt.Errorf("%w: %d", ErrCommentNotFound, id)
+		}
+		if len(comments) > 0 && comment.ParentID != comments[0].ParentID {
+			return CommentTree{}, fmt.Errorf("%w: %d is not a sibling of %d", ErrInvalidChildren, id, comments[0].ID)
+		}
+		comments = append(comments, comment)
+	}
+
+	parentID, depth := 0, 0
+	if len(comments) > 0 {
+		parentID = comments[0].ParentID
+		depth = e.commentDepth(comments[0])
+	}
+	if maxDepth > 0 {
+		maxDepth += depth
+	}
+	budget := commentBudget(limit)
+	nodes, more, err := buildCommentNodes(comments, parentID, depth, sortBy, maxDepth, &budget, moderator)
+	if err != nil {
+		return CommentTree{}, err
+	}
+	return CommentTree{PostID: postID, Comments: nodes, More: more}, nil
+}
+
+func (e *Engine) commentDepth(comment *Comment) int {
+	depth := 0
+	for comment.ParentID != 0 {
+		comment = e.comments[comment.ParentID]
+		depth++
+	}
+	return depth
+}
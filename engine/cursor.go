@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+type feedPosition struct {
+	Score float64 `json:"k"`
+	Time  int64   `json:"t,omitempty"`
+	ID    int     `json:"id"`
+}
+
+type feedCursor struct {
+	Sort string `json:"s"`
+	feedPosition
+}
+
+type rankedPost struct {
+	post     *Post
+	position feedPosition
+}
+
+func (p feedPosition) precedes(other feedPosition) bool {
+	if p.Score != other.Score {
+		return p.Score > other.Score
+	}
+	if p.Time != other.Time {
+		return p.Time > other.Time
+	}
+	return p.ID > other.ID
+}
+
+func (p feedPosition) locate(ranked []rankedPost, by FeedSort) (int, bool) {
+	if by != SortRising {
+		return 0, false
+	}
+	for i, entry := range ranked {
+		if entry.position.ID == p.ID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func encodeCursor(by FeedSort, position feedPosition) string {
+	data, _ := json.Marshal(feedCursor{Sort: by.String(), feedPosition: position})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string, by FeedSort) (feedPosition, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return feedPosition{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var decoded feedCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return feedPosition{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if decoded.Sort != by.String() {
+		return feedPosition{}, fmt.Errorf("%w: cursor is for %s, not %s", ErrInvalidCursor, decoded.Sort, by)
+	}
+	return decoded.feedPosition, nil
+}
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+const DeletedMarker = "[deleted]"
+
+type Revision struct {
+	Content  string
+	EditedAt time.Time
+}
+
+func (e *Engine) requireConnected(username string) error {
+	user, exists := e.Users[username]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	if !user.Connected {
+		return fmt.Errorf("%w: %s", ErrUserNotConnected, username)
+	}
+	return nil
+}
+
+func (e *Engine) canModify(username, author string) error {
+	if username != author {
+		return fmt.Errorf("%w: %s is not %s", ErrNotAuthor, username, author)
+	}
+	return nil
+}
+
+func (e *Engine) canDelete(username, subreddit, author string) error {
+	if username == author {
+		return nil
+	}
+	if sub, exists := e.Subreddits[subreddit]; exists && sub.permissions(username).Has(PermPosts) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is neither %s nor a moderator of %s", ErrNotAuthor, username, author, subreddit)
+}
+
+func (e *Engine) removeAsModerator(moderation *Moderation) error {
+	if err := moderation.apply(ModRemove); err != nil {
+		return err
+	}
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) livePost(postID int) (*Post, error) {
+	post, exists := e.posts[postID]
+	if !exists || post.Deleted {
+		return nil, fmt.Errorf("%w: %d", ErrPostNotFound, postID)
+	}
+	return post, nil
+}
+
+func (e *Engine) liveComment(commentID int) (*Comment, error) {
+	comment, exists := e.comments[commentID]
+	if !exists || comment.Deleted {
+		return nil, fmt.Errorf("%w: %d", ErrCommentNotFound, commentID)
+	}
+	return comment, nil
+}
+
+func (e *Engine) EditPost(username string, postID int, content string) (Post, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(username); err != nil {
+		return Post{}, err
+	}
+	post, err := e.livePost(postID)
+	if err != nil {
+		return Post{}, err
+	}
+	if err := e.canModify(username, post.Author); err != nil {
+		return Post{}, err
+	}
+
+	now := time.Now()
+	post.Revisions = append(post.Revisions, Revision{Content: post.Content, EditedAt: now})
+	post.Content = content
+	post.Edited = now
+	if sub, exists := e.Subreddits[post.Subreddit]; exists {
+		e.autoFilter(sub, username, &post.Moderation, post.Title, post.Content, post.URL)
+	}
+	e.metrics.IncrementOperation()
+
+	edited := *post
+	edited.Comments = copyComments(post.Comments)
+	return edited, nil
+}
+
+func (e *Engine) DeletePost(username string, postID int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(username); err != nil {
+		return err
+	}
+	post, err := e.livePost(postID)
+	if err != nil {
+		return err
+	}
+	if err := e.canDelete(username, post.Subreddit, post.Author); err != nil {
+		return err
+	}
+	if username != post.Author {
+		return e.removeAsModerator(&post.Moderation)
+	}
+
+	if sub, exists := e.Subreddits[post.Subreddit]; exists {
+		for i := range sub.Posts {
+			if sub.Posts[i].ID == postID {
+				sub.Posts = append(sub.Posts[:i], sub.Posts[i+1:]...)
+				break
+			}
+		}
+	}
+	e.adjustKarma(post.Author, -(post.Upvotes - post.Downvotes), 0)
+	post.Author = DeletedMarker
+	post.Content = DeletedMarker
+	post.URL = ""
+	post.Revisions = nil
+	post.Deleted = true
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) EditComment(username string, commentID int, content string) (Comment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(username); err != nil {
+		return Comment{}, err
+	}
+	comment, err := e.liveComment(commentID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if err := e.canModify(username, comment.Author); err != nil {
+		return Comment{}, err
+	}
+
+	now := time.Now()
+	comment.Revisions = append(comment.Revisions, Revision{Content: comment.Content, EditedAt: now})
+	comment.Content = content
+	comment.Edited = now
+	if post, exists := e.posts[comment.PostID]; exists {
+		if sub, exists := e.Subreddits[post.Subreddit]; exists {
+			e.autoFilter(sub, username, &comment.Moderation, comment.Content)
+		}
+	}
+	e.metrics.IncrementOperation()
+
+	edited := *comment
+	edited.Replies = copyComments(comment.Replies)
+	return edited, nil
+}
+
+func (e *Engine) DeleteComment(username string, commentID int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(username); err != nil {
+		return err
+	}
+	comment, err := e.liveComment(commentID)
+	if err != nil {
+		return err
+	}
+	subreddit := ""
+	if post, exists := e.posts[comment.PostID]; exists {
+		subreddit = post.Subreddit
+	}
+	if err := e.canDelete(username, subreddit, comment.Author); err != nil {
+		return err
+	}
+	if username != comment.Author {
+		return e.removeAsModerator(&comment.Moderation)
+	}
+
+	e.adjustKarma(comment.Author, 0, -(comment.Upvotes - comment.Downvotes))
+	comment.Author = DeletedMarker
+	comment.Content = DeletedMarker
+	comment.Revisions = nil
+	comment.Deleted = true
+	e.metrics.IncrementOperation()
+	return nil
+}
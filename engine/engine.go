@@ -3,43 +3,85 @@ package engine
 import (
 	"fmt"
 	"project4/performance"
+	"regexp"
 	"sort"
 	"sync"
 	"time"
 )
 
 type User struct {
-	Username  string
-	Messages  []Message
-	Karma     int
-	Connected bool
+	Username      string
+	Messages      []Message
+	PostKarma     int
+	CommentKarma  int
+	Connected     bool
+	Subscriptions map[string]*Subreddit
 }
 
 type Subreddit struct {
-	Name    string
-	Members map[string]*User
-	Posts   []Post
+	Name        string
+	Owner       string
+	Type        SubredditType
+	Description string
+	Members     map[string]*User
+	Moderators  map[string]ModPermission
+	Approved    map[string]bool
+	Invites     map[string]bool
+	FilterWords []string
+	Bans        map[string]Restriction
+	Mutes       map[string]Restriction
+	Posts       []*Post
+}
+
+type SubredditInfo struct {
+	Name        string
+	Owner       string
+	Type        SubredditType
+	Description string
+	MemberCount int
+	PostCount   int
 }
 
 type Post struct {
 	ID        int
+	Subreddit string
 	Author    string
+	Title     string
+	Kind      PostKind
 	Content   string
+	URL       string
+	NSFW      bool
+	Spoiler   bool
+	Flair     string
 	Comments  []*Comment
 	Upvotes   int
 	Downvotes int
 	Timestamp time.Time
+	Edited    time.Time
+	Revisions []Revision
+	Deleted   bool
+	Moderation
 }
 
 type Comment struct {
 	ID        int
+	PostID    int
+	ParentID  int
 	Author    string
 	Content   string
 	Replies   []*Comment
+	Upvotes   int
+	Downvotes int
 	Timestamp time.Time
+	Edited    time.Time
+	Revisions []Revision
+	Deleted   bool
+	Moderation
 }
 
 type Message struct {
+	ID        int
+	ParentID  int
 	Sender    string
 	Receiver  string
 	Content   string
@@ -52,13 +94,24 @@ type Engine struct {
 	Subreddits   map[string]*Subreddit
 	PostCount    int
 	CommentCount int
+	MessageCount int
 	metrics      *performance.Metrics
+	postVotes    map[postVoteKey]VoteDirection
+	commentVotes map[commentVoteKey]VoteDirection
+	voteLog      map[int][]voteEvent
+	posts        map[int]*Post
+	comments     map[int]*Comment
 }
 
 func NewEngine() *Engine {
 	return &Engine{
-		Users:      make(map[string]*User),
-		Subreddits: make(map[string]*Subreddit),
+		Users:        make(map[string]*User),
+		Subreddits:   make(map[string]*Subreddit),
+		postVotes:    make(map[postVoteKey]VoteDirection),
+		commentVotes: make(map[commentVoteKey]VoteDirection),
+		voteLog:      make(map[int][]voteEvent),
+		posts:        make(map[int]*Post),
+		comments:     make(map[int]*Comment),
 	}
 }
 
@@ -66,15 +119,28 @@ func (e *Engine) SetMetrics(metrics *performance.Metrics) {
 	e.metrics = metrics
 }
 
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: %q", ErrInvalidUsername, username)
+	}
+	return nil
+}
+
 func (e *Engine) RegisterUser(username string) error {
+	if err := ValidateUsername(username); err != nil {
+		return err
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if _, exists := e.Users[username]; exists {
-		return fmt.Errorf("user %s already exists", username)
+		return fmt.Errorf("%w: %s", ErrUserExists, username)
 	}
 
-	e.Users[username] = &User{Username: username, Karma: 0}
+	e.Users[username] = &User{Username: username, Subscriptions: make(map[string]*Subreddit)}
 	e.metrics.IncrementOperation()
 	return nil
 }
@@ -84,277 +150,262 @@ func (e *Engine) CreateSubreddit(name string) error {
 	defer e.mu.Unlock()
 
 	if _, exists := e.Subreddits[name]; exists {
-		return fmt.Errorf("subreddit %s already exists", name)
+		return fmt.Errorf("%w: %s", ErrSubredditExists, name)
 	}
 
-	e.Subreddits[name] = &Subreddit{Name: name, Members: make(map[string]*User)}
+	e.Subreddits[name] = newSubreddit(name, "", TypePublic)
 	e.metrics.IncrementOperation()
 	return nil
 }
 
-func (e *Engine) JoinSubreddit(username, subreddit string) error {
+func newSubreddit(name, owner string, kind SubredditType) *Subreddit {
+	return &Subreddit{
+		Name:       name,
+		Owner:      owner,
+		Type:       kind,
+		Members:    make(map[string]*User),
+		Moderators: make(map[string]ModPermission),
+		Approved:   make(map[string]bool),
+		Invites:    make(map[string]bool),
+		Bans:       make(map[string]Restriction),
+		Mutes:      make(map[string]Restriction),
+	}
+}
+
+func (e *Engine) ListSubreddits() []string {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	user, userExists := e.Users[username]
-	sub, subExists := e.Subreddits[subreddit]
-	if !userExists || !subExists {
-		return fmt.Errorf("invalid user or subreddit")
+	names := make([]string, 0, len(e.Subreddits))
+	for name := range e.Subreddits {
+		names = append(names, name)
 	}
-
-	sub.Members[username] = user
-	e.metrics.IncrementOperation()
-	return nil
+	sort.Strings(names)
+	return names
 }
 
-func (e *Engine) LeaveSubreddit(username, subreddit string) error {
+func (e *Engine) GetSubreddit(name string) (SubredditInfo, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	sub, exists := e.Subreddits[subreddit]
+	sub, exists := e.Subreddits[name]
 	if !exists {
-		return fmt.Errorf("subreddit does not exist")
+		return SubredditInfo{}, fmt.Errorf("%w: %s", ErrSubredditNotFound, name)
 	}
 
-	_, memberExists := sub.Members[username]
-	if !memberExists {
-		return fmt.Errorf("user %s is not a member of subreddit %s", username, subreddit)
-	}
+	return sub.info(), nil
+}
 
-	delete(sub.Members, username)
-	e.metrics.IncrementOperation()
-	return nil
+func (sub *Subreddit) info() SubredditInfo {
+	return SubredditInfo{
+		Name:        sub.Name,
+		Owner:       sub.Owner,
+		Type:        sub.Type,
+		Description: sub.Description,
+		MemberCount: len(sub.Members),
+		PostCount:   len(sub.Posts),
+	}
 }
 
-func (e *Engine) PostInSubreddit(username, subreddit, content string) (int, error) {
+func (e *Engine) ListMembers(subreddit string) ([]string, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	user, userExists := e.Users[username]
-	sub, subExists := e.Subreddits[subreddit]
-	if !userExists || !subExists {
-		return 0, fmt.Errorf("subreddit does not exist")
-	}
-
-	if !user.Connected {
-		return 0, fmt.Errorf("user %s is not connected", username)
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
 	}
 
-	post := Post{
-		ID:        e.PostCount + 1,
-		Author:    username,
-		Content:   content,
-		Comments:  []*Comment{},
-		Timestamp: time.Now(),
+	members := make([]string, 0, len(sub.Members))
+	for username := range sub.Members {
+		members = append(members, username)
 	}
-	e.PostCount++
-	sub.Posts = append(sub.Posts, post)
-	e.metrics.IncrementOperation()
-	return post.ID, nil
+	sort.Strings(members)
+	return members, nil
 }
 
-func (e *Engine) CommentOnPost(username, subreddit string, postID int, content string) error {
+func (e *Engine) JoinSubreddit(username, subreddit string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	user, userExists := e.Users[username]
+	if !userExists {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
 	sub, subExists := e.Subreddits[subreddit]
-	if !userExists || !subExists {
-		return fmt.Errorf("subreddit does not exist")
+	if !subExists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
 	}
-
-	if !user.Connected {
-		return fmt.Errorf("user %s is not connected", username)
-	}
-
-	for i := range sub.Posts {
-		if sub.Posts[i].ID == postID {
-			comment := &Comment{
-				ID:        e.CommentCount + 1,
-				Author:    username,
-				Content:   content,
-				Replies:   []*Comment{},
-				Timestamp: time.Now(),
-			}
-			sub.Posts[i].Comments = append(sub.Posts[i].Comments, comment)
-			e.CommentCount++
-			e.metrics.IncrementOperation()
-			fmt.Printf("Comment added by user %s on post %d in subreddit %s\n", username, postID, subreddit)
-			return nil
+	if err := e.checkBanned(sub, username); err != nil {
+		return err
+	}
+	if sub.Type == TypePrivate && !sub.Invites[username] && !sub.IsModerator(username) {
+		if _, member := sub.Members[username]; !member {
+			return fmt.Errorf("%w: %s requires an invite", ErrPrivateSubreddit, subreddit)
 		}
 	}
 
-	return fmt.Errorf("post not found")
+	delete(sub.Invites, username)
+	sub.Members[username] = user
+	user.Subscriptions[subreddit] = sub
+	e.metrics.IncrementOperation()
+	return nil
 }
 
-func (e *Engine) ReplyToComment(subreddit string, postID, parentCommentID int, username, content string) error {
+func (e *Engine) LeaveSubreddit(username, subreddit string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	sub, exists := e.Subreddits[subreddit]
 	if !exists {
-		return fmt.Errorf("subreddit does not exist")
-	}
-
-	for i := range sub.Posts {
-		if sub.Posts[i].ID == postID {
-			parent := findCommentByID(sub.Posts[i].Comments, parentCommentID)
-			if parent == nil {
-				return fmt.Errorf("comment not found")
-			}
-			reply := &Comment{
-				ID:        e.CommentCount + 1,
-				Author:    username,
-				Content:   content,
-				Timestamp: time.Now(),
-			}
-			parent.Replies = append(parent.Replies, reply)
-			e.CommentCount++
-			e.metrics.IncrementOperation()
-			return nil
-		}
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
 	}
 
-	return fmt.Errorf("post not found")
-}
+	_, memberExists := sub.Members[username]
+	if !memberExists {
+		return fmt.Errorf("%w: %s is not in %s", ErrNotMember, username, subreddit)
+	}
 
-func findCommentByID(comments []*Comment, id int) *Comment {
-	for _, comment := range comments {
-		if comment.ID == id {
-			return comment
-		}
-		for _, reply := range comment.Replies {
-			if found := findCommentByID(reply.Replies, id); found != nil {
-				return found
-			}
-		}
+	delete(sub.Members, username)
+	if user, exists := e.Users[username]; exists {
+		delete(user.Subscriptions, subreddit)
 	}
+	e.metrics.IncrementOperation()
 	return nil
 }
 
-func (e *Engine) UpvotePost(subreddit string, postID int) error {
+func (e *Engine) PostInSubreddit(username, subreddit, content string) (int, error) {
+	return e.PostInSubredditAt(username, subreddit, content, time.Now())
+}
+
+func (e *Engine) PostInSubredditAt(username, subreddit, content string, at time.Time) (int, error) {
+	return e.SubmitPostAt(username, subreddit, PostDraft{Kind: KindText, Content: content}, at)
+}
+
+func (e *Engine) CommentOnPost(username, subreddit string, postID int, content string) (int, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	sub, exists := e.Subreddits[subreddit]
-	if !exists {
-		return fmt.Errorf("subreddit does not exist")
+	user, userExists := e.Users[username]
+	if !userExists {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	}
-
-	for i := range sub.Posts {
-		if sub.Posts[i].ID == postID {
-			sub.Posts[i].Upvotes++
-			e.metrics.IncrementOperation()
-			fmt.Printf("Post %d in subreddit %s upvoted. Total upvotes: %d\n", postID, subreddit, sub.Posts[i].Upvotes)
-			return nil
-		}
+	sub, subExists := e.Subreddits[subreddit]
+	if !subExists {
+		return 0, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
 	}
-	return fmt.Errorf("post not found")
-}
 
-func (e *Engine) DownvotePost(subreddit string, postID int) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	if !user.Connected {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotConnected, username)
+	}
+	if err := e.checkCanContribute(sub, username); err != nil {
+		return 0, err
+	}
+	if err := e.checkCanView(sub, username); err != nil {
+		return 0, err
+	}
 
-	sub, exists := e.Subreddits[subreddit]
-	if !exists {
-		return fmt.Errorf("subreddit does not exist")
+	post, err := e.postIn(sub, postID)
+	if err != nil {
+		return 0, err
 	}
 
-	for i := range sub.Posts {
-		if sub.Posts[i].ID == postID {
-			sub.Posts[i].Downvotes++
-			e.metrics.IncrementOperation()
-			fmt.Printf("Post %d in subreddit %s downvoted. Total downvotes: %d\n", postID, subreddit, sub.Posts[i].Downvotes)
-			return nil
-		}
+	comment := &Comment{
+		ID:        e.CommentCount + 1,
+		PostID:    post.ID,
+		Author:    username,
+		Content:   content,
+		Replies:   []*Comment{},
+		Timestamp: time.Now(),
 	}
-	return fmt.Errorf("post not found")
+	e.autoFilter(sub, username, &comment.Moderation, content)
+	post.Comments = append(post.Comments, comment)
+	e.comments[comment.ID] = comment
+	e.CommentCount++
+	e.metrics.IncrementOperation()
+	fmt.Printf("Comment added by user %s on post %d in subreddit %s\n", username, postID, subreddit)
+	return comment.ID, nil
 }
 
-func (e *Engine) ComputeKarma(username string) int {
+func (e *Engine) ReplyToComment(subreddit string, postID, parentCommentID int, username, content string) (int, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	user, exists := e.Users[username]
+	user, userExists := e.Users[username]
+	if !userExists {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	sub, exists := e.Subreddits[subreddit]
 	if !exists {
-		return 0
-	}
-
-	karma := 0
-	for _, sub := range e.Subreddits {
-		for _, post := range sub.Posts {
-			if post.Author == username {
-				karma += post.Upvotes - post.Downvotes
-			}
-			for _, comment := range post.Comments {
-				karma += computeCommentKarma(comment, username)
-			}
-		}
+		return 0, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
 	}
 
-	user.Karma = karma
-	fmt.Printf("Computed karma for user %s: %d\n", username, karma)
-	e.metrics.IncrementOperation()
-	return karma
-}
-
-func computeCommentKarma(comment *Comment, username string) int {
-	karma := 0
-	if comment.Author == username {
-		karma++
+	if !user.Connected {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotConnected, username)
 	}
-	for _, reply := range comment.Replies {
-		karma += computeCommentKarma(reply, username)
+	if err := e.checkCanContribute(sub, username); err != nil {
+		return 0, err
 	}
-	return karma
-}
-
-func (e *Engine) UpdateAllUsersKarma() {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	for username := range e.Users {
-		e.ComputeKarma(username)
+	if err := e.checkCanView(sub, username); err != nil {
+		return 0, err
 	}
-}
-
-func (e *Engine) GetUserKarma(username string) (int, error) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
 
-	user, exists := e.Users[username]
-	if !exists {
-		return 0, fmt.Errorf("user %s does not exist", username)
+	post, err := e.postIn(sub, postID)
+	if err != nil {
+		return 0, err
+	}
+	parent, err := e.commentOn(post, parentCommentID)
+	if err != nil {
+		return 0, err
 	}
 
-	return user.Karma, nil
+	reply := &Comment{
+		ID:        e.CommentCount + 1,
+		PostID:    post.ID,
+		ParentID:  parent.ID,
+		Author:    username,
+		Content:   content,
+		Replies:   []*Comment{},
+		Timestamp: time.Now(),
+	}
+	e.autoFilter(sub, username, &reply.Moderation, content)
+	parent.Replies = append(parent.Replies, reply)
+	e.comments[reply.ID] = reply
+	e.CommentCount++
+	e.metrics.IncrementOperation()
+	return reply.ID, nil
 }
 
-func (e *Engine) GetFeed(subreddit string, sortBy string, limit int) ([]Post, error) {
+func (e *Engine) GetComments(viewer, subreddit string, postID int) ([]*Comment, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	sub, exists := e.Subreddits[subreddit]
 	if !exists {
-		return nil, fmt.Errorf("subreddit not found")
+		return nil, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.checkCanView(sub, viewer); err != nil {
+		return nil, err
 	}
 
-	posts := append([]Post{}, sub.Posts...)
-	switch sortBy {
-	case "upvotes":
-		sort.Slice(posts, func(i, j int) bool { return posts[i].Upvotes > posts[j].Upvotes })
-	case "time":
-		sort.Slice(posts, func(i, j int) bool { return posts[i].Timestamp.After(posts[j].Timestamp) })
-	default:
-		return nil, fmt.Errorf("invalid sort criteria")
+	post, moderator, err := e.viewPost(viewer, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Subreddit != sub.Name || post.Deleted {
+		return nil, fmt.Errorf("%w: %d", ErrPostNotFound, postID)
 	}
+	return viewComments(post.Comments, moderator), nil
+}
 
-	if len(posts) > limit {
-		posts = posts[:limit]
+func copyComments(comments []*Comment) []*Comment {
+	copied := make([]*Comment, 0, len(comments))
+	for _, comment := range comments {
+		c := *comment
+		c.Replies = copyComments(comment.Replies)
+		copied = append(copied, &c)
 	}
-	e.metrics.IncrementOperation()
-	return posts, nil
+	return copied
 }
 
 func (e *Engine) ConnectUser(username string) error {
@@ -363,7 +414,7 @@ func (e *Engine) ConnectUser(username string) error {
 
 	user, exists := e.Users[username]
 	if !exists {
-		return fmt.Errorf("user %s does not exist", username)
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	}
 
 	user.Connected = true
@@ -371,13 +422,21 @@ func (e *Engine) ConnectUser(username string) error {
 	return nil
 }
 
+func (e *Engine) IsConnected(username string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, exists := e.Users[username]
+	return exists && user.Connected
+}
+
 func (e *Engine) DisconnectUser(username string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	user, exists := e.Users[username]
 	if !exists {
-		return fmt.Errorf("user %s does not exist", username)
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	}
 
 	user.Connected = false
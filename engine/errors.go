@@ -0,0 +1,37 @@
+package engine
+
+import "errors"
+
+var (
+	ErrUserNotFound         = errors.New("user does not exist")
+	ErrUserExists           = errors.New("user already exists")
+	ErrInvalidUsername      = errors.New("username must be 3-20 letters, digits, underscores or hyphens")
+	ErrUserNotConnected     = errors.New("user is not connected")
+	ErrSubredditNotFound    = errors.New("subreddit does not exist")
+	ErrSubredditExists      = errors.New("subreddit already exists")
+	ErrNotMember            = errors.New("user is not a member of subreddit")
+	ErrPostNotFound         = errors.New("post not found")
+	ErrCommentNotFound      = errors.New("comment not found")
+	ErrMessageNotFound      = errors.New("message not found")
+	ErrNotAuthor            = errors.New("only the author may modify this")
+	ErrInvalidSort          = errors.New("invalid sort criteria")
+	ErrInvalidTimeWindow    = errors.New("invalid time window")
+	ErrInvalidCursor        = errors.New("invalid pagination cursor")
+	ErrInvalidChildren      = errors.New("children must share a parent")
+	ErrInvalidPost          = errors.New("invalid post")
+	ErrInvalidFilter        = errors.New("invalid feed filter")
+	ErrNotModerator         = errors.New("user is not a moderator of subreddit")
+	ErrPermissionDenied     = errors.New("insufficient moderator permissions")
+	ErrInvalidPermission    = errors.New("invalid moderator permission")
+	ErrBanned               = errors.New("user is banned")
+	ErrMuted                = errors.New("user is muted")
+	ErrNotBanned            = errors.New("user is not banned")
+	ErrNotMuted             = errors.New("user is not muted")
+	ErrInvalidDuration      = errors.New("invalid duration")
+	ErrInvalidSubredditType = errors.New("invalid subreddit type")
+	ErrPrivateSubreddit     = errors.New("subreddit is private")
+	ErrNotApproved          = errors.New("user is not an approved submitter")
+	ErrInvalidReport        = errors.New("invalid report")
+	ErrAlreadyReported      = errors.New("already reported")
+	ErrInvalidModAction     = errors.New("invalid moderation action")
+)
@@ -0,0 +1,367 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FeedSort int
+
+const (
+	SortHot FeedSort = iota
+	SortTop
+	SortNew
+	SortControversial
+	SortRising
+)
+
+const (
+	hotEpoch     = 1134028003
+	hotDecay     = 45000
+	risingWindow = time.Hour
+	DefaultSort  = SortHot
+)
+
+var feedSortNames = map[FeedSort]string{
+	SortHot:           "hot",
+	SortTop:           "top",
+	SortNew:           "new",
+	SortControversial: "controversial",
+	SortRising:        "rising",
+}
+
+type TimeWindow int
+
+const (
+	WindowAll TimeWindow = iota
+	WindowHour
+	WindowDay
+	WindowWeek
+	WindowMonth
+	WindowYear
+)
+
+var timeWindowNames = map[TimeWindow]string{
+	WindowAll:   "all",
+	WindowHour:  "hour",
+	WindowDay:   "day",
+	WindowWeek:  "week",
+	WindowMonth: "month",
+	WindowYear:  "year",
+}
+
+var timeWindowDurations = map[TimeWindow]time.Duration{
+	WindowHour:  time.Hour,
+	WindowDay:   24 * time.Hour,
+	WindowWeek:  7 * 24 * time.Hour,
+	WindowMonth: 30 * 24 * time.Hour,
+	WindowYear:  365 * 24 * time.Hour,
+}
+
+type FeedOptions struct {
+	Sort   FeedSort
+	Window TimeWindow
+	Limit  int
+	After  string
+	Before string
+	Flair  string
+	NSFW   NSFWFilter
+	Viewer string
+}
+
+type FeedPage struct {
+	Posts  []Post
+	After  string
+	Before string
+	Total  int
+}
+
+type voteEvent struct {
+	at    time.Time
+	delta int
+}
+
+func (s FeedSort) String() string {
+	if name, ok := feedSortNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("FeedSort(%d)", int(s))
+}
+
+func ParseFeedSort(name string) (FeedSort, error) {
+	for sort, sortName := range feedSortNames {
+		if strings.EqualFold(name, sortName) {
+			return sort, nil
+		}
+	}
+	return DefaultSort, fmt.Errorf("%w: %q", ErrInvalidSort, name)
+}
+
+func (w TimeWindow) String() string {
+	if name, ok := timeWindowNames[w]; ok {
+		return name
+	}
+	return fmt.Sprintf("TimeWindow(%d)", int(w))
+}
+
+func (w TimeWindow) Duration() time.Duration {
+	return timeWindowDurations[w]
+}
+
+func (w TimeWindow) Contains(timestamp, now time.Time) bool {
+	duration, bounded := timeWindowDurations[w]
+	return !bounded || !timestamp.Before(now.Add(-duration))
+}
+
+func ParseTimeWindow(name string) (TimeWindow, error) {
+	for window, windowName := range timeWindowNames {
+		if strings.EqualFold(name, windowName) {
+			return window, nil
+		}
+	}
+	return WindowAll, fmt.Errorf("%w: %q", ErrInvalidTimeWindow, name)
+}
+
+func HotScore(post Post) float64 {
+	score := float64(post.Upvotes - post.Downvotes)
+	order := math.Log10(math.Max(math.Abs(score), 1))
+	sign := 0.0
+	if score > 0 {
+		sign = 1
+	} else if score < 0 {
+		sign = -1
+	}
+	seconds := float64(post.Timestamp.Unix() - hotEpoch)
+	return sign*order + seconds/hotDecay
+}
+
+func ControversyScore(post Post) float64 {
+	return controversy(post.Upvotes, post.Downvotes)
+}
+
+func controversy(upvotes, downvotes int) float64 {
+	if upvotes <= 0 || downvotes <= 0 {
+		return 0
+	}
+	magnitude := float64(upvotes + downvotes)
+	balance := float64(downvotes) / float64(upvotes)
+	if upvotes < downvotes {
+		balance = float64(upvotes) / float64(downvotes)
+	}
+	return math.Pow(magnitude, balance)
+}
+
+func (e *Engine) recordVote(postID, delta int, now time.Time) {
+	events := e.voteLog[postID]
+	cutoff := now.Add(-risingWindow)
+	start := 0
+	for start < len(events) && events[start].at.Before(cutoff) {
+		start++
+	}
+	e.voteLog[postID] = append(events[start:], voteEvent{at: now, delta: delta})
+}
+
+func (e *Engine) risingScore(postID int, now time.Time) float64 {
+	cutoff := now.Add(-risingWindow)
+	velocity := 0
+	for _, event := range e.voteLog[postID] {
+		if !event.at.Before(cutoff) {
+			velocity += event.delta
+		}
+	}
+	return float64(velocity) / risingWindow.Hours()
+}
+
+func (e *Engine) rankPosts(posts []*Post, by FeedSort, now time.Time) ([]rankedPost, error) {
+	var key func(Post) float64
+	switch by {
+	case SortHot:
+		key = HotScore
+	case SortTop:
+		key = func(post Post) float64 { return float64(post.Upvotes - post.Downvotes) }
+	case SortNew:
+		key = func(Post) float64 { return 0 }
+	case SortControversial:
+		key = ControversyScore
+	case SortRising:
+		key = func(post Post) float64 { return e.risingScore(post.ID, now) }
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSort, by)
+	}
+
+	ranked := make([]rankedPost, 0, len(posts))
+	for _, post := range posts {
+		position := feedPosition{Score: key(*post), ID: post.ID}
+		if by == SortNew {
+			position.Time = post.Timestamp.UnixNano()
+		}
+		ranked = append(ranked, rankedPost{post: post, position: position})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].position.precedes(ranked[j].position) })
+	return ranked, nil
+}
+
+func (e *Engine) GetFeed(subreddit string, sortBy FeedSort, limit int) ([]Post, error) {
+	page, err := e.QueryFeed(subreddit, FeedOptions{Sort: sortBy, Window: WindowAll, Limit: limit})
+	return page.Posts, err
+}
+
+func (e *Engine) GetAllFeed(sortBy FeedSort, limit int) ([]Post, error) {
+	page, err := e.QueryAllFeed(FeedOptions{Sort: sortBy, Window: WindowAll, Limit: limit})
+	return page.Posts, err
+}
+
+func (e *Engine) QueryFeed(subreddit string, options FeedOptions) (FeedPage, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return FeedPage{}, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.checkCanView(sub, options.Viewer); err != nil {
+		return FeedPage{}, err
+	}
+	return e.queryPosts(sub.Posts, options, time.Now())
+}
+
+func (e *Engine) QueryAllFeed(options FeedOptions) (FeedPage, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	posts := []*Post{}
+	for _, sub := range e.Subreddits {
+		if sub.canView(options.Viewer) {
+			posts = append(posts, sub.Posts...)
+		}
+	}
+	return e.queryPosts(posts, options, time.Now())
+}
+
+func (e *Engine) GetHomeFeed(username string, sortBy FeedSort, cursor string, limit int) (FeedPage, error) {
+	return e.QueryHomeFeed(username, FeedOptions{Sort: sortBy, Window: WindowAll, Limit: limit, After: cursor})
+}
+
+func (e *Engine) QueryHomeFeed(username string, options FeedOptions) (FeedPage, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, exists := e.Users[username]
+	if !exists {
+		return FeedPage{}, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	posts := []*Post{}
+	for _, sub := range user.Subscriptions {
+		if sub.canView(username) {
+			posts = append(posts, sub.Posts...)
+		}
+	}
+	options.Viewer = username
+	return e.queryPosts(posts, options, time.Now())
+}
+
+func (e *Engine) ListSubscriptions(username string) ([]string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, exists := e.Users[username]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	subscriptions := make([]string, 0, len(user.Subscriptions))
+	for name := range user.Subscriptions {
+		subscriptions = append(subscriptions, name)
+	}
+	sort.Strings(subscriptions)
+	return subscriptions, nil
+}
+
+func (options FeedOptions) matches(post Post) bool {
+	if options.Flair != "" && !strings.EqualFold(post.Flair, options.Flair) {
+		return false
+	}
+	return options.NSFW.Matches(post)
+}
+
+func (e *Engine) feedView(post *Post, viewer string) (bool, bool) {
+	sub, exists := e.Subreddits[post.Subreddit]
+	moderator := exists && sub.IsModerator(viewer)
+	return !post.Removed || moderator, moderator
+}
+
+func (e *Engine) queryPosts(candidates []*Post, options FeedOptions, now time.Time) (FeedPage, error) {
+	if _, ok := timeWindowNames[options.Window]; !ok {
+		return FeedPage{}, fmt.Errorf("%w: %s", ErrInvalidTimeWindow, options.Window)
+	}
+	if _, ok := nsfwFilterNames[options.NSFW]; !ok {
+		return FeedPage{}, fmt.Errorf("%w: %s", ErrInvalidFilter, options.NSFW)
+	}
+	if options.After != "" && options.Before != "" {
+		return FeedPage{}, fmt.Errorf("%w: after and before are mutually exclusive", ErrInvalidCursor)
+	}
+
+	posts := []*Post{}
+	for _, post := range candidates {
+		visible, _ := e.feedView(post, options.Viewer)
+		if visible && options.Window.Contains(post.Timestamp, now) && options.matches(*post) {
+			posts = append(posts, post)
+		}
+	}
+	ranked, err := e.rankPosts(posts, options.Sort, now)
+	if err != nil {
+		return FeedPage{}, err
+	}
+
+	start, end := 0, len(ranked)
+	switch {
+	case options.After != "":
+		after, err := decodeCursor(options.After, options.Sort)
+		if err != nil {
+			return FeedPage{}, err
+		}
+		if i, found := after.locate(ranked, options.Sort); found {
+			start = i + 1
+		} else {
+			start = sort.Search(len(ranked), func(i int) bool { return after.precedes(ranked[i].position) })
+		}
+		if options.Limit > 0 && end-start > options.Limit {
+			end = start + options.Limit
+		}
+	case options.Before != "":
+		before, err := decodeCursor(options.Before, options.Sort)
+		if err != nil {
+			return FeedPage{}, err
+		}
+		if i, found := before.locate(ranked, options.Sort); found {
+			end = i
+		} else {
+			end = sort.Search(len(ranked), func(i int) bool { return !ranked[i].position.precedes(before) })
+		}
+		if options.Limit > 0 && end-start > options.Limit {
+			start = end - options.Limit
+		}
+	default:
+		if options.Limit > 0 && end > options.Limit {
+			end = options.Limit
+		}
+	}
+
+	page := FeedPage{Posts: make([]Post, 0, end-start), Total: len(ranked)}
+	for _, entry := range ranked[start:end] {
+		_, moderator := e.feedView(entry.post, options.Viewer)
+		page.Posts = append(page.Posts, entry.post.view(moderator))
+	}
+	if start > 0 && start < len(ranked) {
+		page.Before = encodeCursor(options.Sort, ranked[start].position)
+	}
+	if end < len(ranked) && end > 0 {
+		page.After = encodeCursor(options.Sort, ranked[end-1].position)
+	}
+	e.metrics.IncrementOperation()
+	return page, nil
+}
@@ -0,0 +1,81 @@
+package engine
+
+import "fmt"
+
+func (e *Engine) postIn(sub *Subreddit, postID int) (*Post, error) {
+	post, exists := e.posts[postID]
+	if !exists || post.Subreddit != sub.Name || post.Deleted || post.Removed {
+		return nil, fmt.Errorf("%w: %d", ErrPostNotFound, postID)
+	}
+	return post, nil
+}
+
+func (e *Engine) commentOn(post *Post, commentID int) (*Comment, error) {
+	comment, exists := e.comments[commentID]
+	if !exists || comment.PostID != post.ID || comment.Deleted || comment.Removed {
+		return nil, fmt.Errorf("%w: %d", ErrCommentNotFound, commentID)
+	}
+	return comment, nil
+}
+
+func (e *Engine) GetPost(id int) (Post, error) {
+	return e.GetPostAs("", id)
+}
+
+func (e *Engine) GetComment(id int) (Comment, error) {
+	return e.GetCommentAs("", id)
+}
+
+func (e *Engine) GetPostAs(viewer string, id int) (Post, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	post, moderator, err := e.viewPost(viewer, id)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post.view(moderator), nil
+}
+
+func (e *Engine) GetCommentAs(viewer string, id int) (Comment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	comment, exists := e.comments[id]
+	if !exists {
+		return Comment{}, fmt.Errorf("%w: %d", ErrCommentNotFound, id)
+	}
+	_, moderator, err := e.viewPost(viewer, comment.PostID)
+	if err != nil {
+		return Comment{}, err
+	}
+	return *viewComments([]*Comment{comment}, moderator)[0], nil
+}
+
+func (post *Post) view(moderator bool) Post {
+	copied := *post
+	copied.Comments = viewComments(post.Comments, moderator)
+	if !moderator {
+		copied.Reports = nil
+	}
+	return copied
+}
+
+func viewComments(comments []*Comment, moderator bool) []*Comment {
+	viewed := make([]*Comment, 0, len(comments))
+	for _, comment := range comments {
+		c := *comment
+		c.Replies = viewComments(comment.Replies, moderator)
+		if !moderator {
+			c.Reports = nil
+			if c.Removed {
+				c.Author = RemovedMarker
+				c.Content = RemovedMarker
+				c.Revisions = nil
+			}
+		}
+		viewed = append(viewed, &c)
+	}
+	return viewed
+}
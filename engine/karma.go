@@ -0,0 +1,82 @@
+package engine
+
+import "fmt"
+
+type Karma struct {
+	Post    int
+	Comment int
+}
+
+func (k Karma) Total() int {
+	return k.Post + k.Comment
+}
+
+func (e *Engine) GetUserKarma(username string) (int, error) {
+	karma, err := e.GetKarma(username)
+	return karma.Total(), err
+}
+
+func (e *Engine) GetKarma(username string) (Karma, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, exists := e.Users[username]
+	if !exists {
+		return Karma{}, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	return Karma{Post: user.PostKarma, Comment: user.CommentKarma}, nil
+}
+
+func (e *Engine) adjustKarma(author string, post, comment int) {
+	if user, exists := e.Users[author]; exists {
+		user.PostKarma += post
+		user.CommentKarma += comment
+	}
+}
+
+func (e *Engine) ComputeKarma(username string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.computeKarma(username).Total()
+}
+
+func (e *Engine) computeKarma(username string) Karma {
+	karma := Karma{}
+	for _, post := range e.posts {
+		if post.Author == username {
+			karma.Post += post.Upvotes - post.Downvotes
+		}
+	}
+	for _, comment := range e.comments {
+		if comment.Author == username {
+			karma.Comment += comment.Upvotes - comment.Downvotes
+		}
+	}
+	return karma
+}
+
+func (e *Engine) VerifyKarma() map[string]Karma {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	mismatched := make(map[string]Karma)
+	for username, user := range e.Users {
+		if computed := e.computeKarma(username); computed.Post != user.PostKarma || computed.Comment != user.CommentKarma {
+			mismatched[username] = computed
+		}
+	}
+	return mismatched
+}
+
+func (e *Engine) UpdateAllUsersKarma() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for username, user := range e.Users {
+		karma := e.computeKarma(username)
+		user.PostKarma = karma.Post
+		user.CommentKarma = karma.Comment
+	}
+	e.metrics.IncrementOperation()
+}
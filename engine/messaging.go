@@ -5,24 +5,35 @@ import (
 	"time"
 )
 
-func (e *Engine) SendMessage(sender, receiver, content string) error {
+func (e *Engine) SendMessage(sender, receiver, content string) (int, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	return e.sendMessage(sender, receiver, content, 0)
+}
+
+func (e *Engine) sendMessage(sender, receiver, content string, parentID int) (int, error) {
+	if _, exists := e.Users[sender]; !exists {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotFound, sender)
+	}
 	receiverUser, exists := e.Users[receiver]
 	if !exists {
-		return fmt.Errorf("receiver %s does not exist", receiver)
+		return 0, fmt.Errorf("%w: %s", ErrUserNotFound, receiver)
 	}
 
 	message := Message{
+		ID:        e.MessageCount + 1,
+		ParentID:  parentID,
 		Sender:    sender,
 		Receiver:  receiver,
 		Content:   content,
 		Timestamp: time.Now(),
 	}
+	e.MessageCount++
 
 	receiverUser.Messages = append(receiverUser.Messages, message)
-	return nil
+	e.metrics.IncrementOperation()
+	return message.ID, nil
 }
 
 func (e *Engine) ListMessages(username string) ([]Message, error) {
@@ -31,12 +42,25 @@ func (e *Engine) ListMessages(username string) ([]Message, error) {
 
 	user, exists := e.Users[username]
 	if !exists {
-		return nil, fmt.Errorf("user %s does not exist", username)
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	}
 
-	return user.Messages, nil
+	return append([]Message{}, user.Messages...), nil
 }
 
-func (e *Engine) ReplyToMessage(sender, receiver, replyContent string) error {
-	return e.SendMessage(sender, receiver, replyContent)
+func (e *Engine) ReplyToMessage(sender string, messageID int, replyContent string) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, exists := e.Users[sender]
+	if !exists {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotFound, sender)
+	}
+
+	for _, message := range user.Messages {
+		if message.ID == messageID {
+			return e.sendMessage(sender, message.Sender, replyContent, messageID)
+		}
+	}
+	return 0, fmt.Errorf("%w: %d", ErrMessageNotFound, messageID)
 }
@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ModPermission int
+
+const (
+	PermPosts ModPermission = 1 << iota
+	PermUsers
+	PermConfig
+
+	PermNone ModPermission = 0
+	PermAll                = PermPosts | PermUsers | PermConfig
+)
+
+var modPermissionNames = map[ModPermission]string{
+	PermPosts:  "posts",
+	PermUsers:  "users",
+	PermConfig: "config",
+}
+
+type Moderator struct {
+	Username    string
+	Permissions ModPermission
+	Owner       bool
+}
+
+type SubredditSettings struct {
+	Description string
+}
+
+func (p ModPermission) Has(required ModPermission) bool {
+	return p&required == required
+}
+
+func (p ModPermission) Names() []string {
+	names := []string{}
+	for permission, name := range modPermissionNames {
+		if p.Has(permission) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p ModPermission) String() string {
+	if p == PermNone {
+		return "none"
+	}
+	return strings.Join(p.Names(), ",")
+}
+
+func ParseModPermissions(names []string) (ModPermission, error) {
+	permissions := PermNone
+	for _, name := range names {
+		if strings.EqualFold(name, "all") {
+			permissions |= PermAll
+			continue
+		}
+		matched := false
+		for permission, permissionName := range modPermissionNames {
+			if strings.EqualFold(name, permissionName) {
+				permissions |= permission
+				matched = true
+			}
+		}
+		if !matched {
+			return PermNone, fmt.Errorf("%w: %q", ErrInvalidPermission, name)
+		}
+	}
+	return permissions, nil
+}
+
+func (sub *Subreddit) permissions(username string) ModPermission {
+	if sub.Owner != "" && sub.Owner == username {
+		return PermAll
+	}
+	return sub.Moderators[username]
+}
+
+func (sub *Subreddit) IsModerator(username string) bool {
+	return sub.permissions(username) != PermNone
+}
+
+func (e *Engine) requirePermission(sub *Subreddit, username string, required ModPermission) error {
+	if !sub.permissions(username).Has(required) {
+		return fmt.Errorf("%w: %s needs %s in %s", ErrPermissionDenied, username, required, sub.Name)
+	}
+	return nil
+}
+
+func (e *Engine) requireOwner(sub *Subreddit, username string) error {
+	if sub.Owner == "" || sub.Owner != username {
+		return fmt.Errorf("%w: only the owner of %s may manage moderators", ErrPermissionDenied, sub.Name)
+	}
+	return nil
+}
+
+func (e *Engine) CreateSubredditAs(owner, name string, kind SubredditType) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(owner); err != nil {
+		return err
+	}
+	if _, exists := e.Subreddits[name]; exists {
+		return fmt.Errorf("%w: %s", ErrSubredditExists, name)
+	}
+	if _, ok := subredditTypeNames[kind]; !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidSubredditType, kind)
+	}
+
+	e.Subreddits[name] = newSubreddit(name, owner, kind)
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) AddModerator(actor, subreddit, username string, permissions ModPermission) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(actor); err != nil {
+		return err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requireOwner(sub, actor); err != nil {
+		return err
+	}
+	if _, exists := e.Users[username]; !exists {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	if permissions == PermNone || permissions&^PermAll != 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidPermission, int(permissions))
+	}
+	if username == sub.Owner {
+		return fmt.Errorf("%w: the owner of %s already has every permission", ErrInvalidPermission, subreddit)
+	}
+
+	sub.Moderators[username] = permissions
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) RemoveModerator(actor, subreddit, username string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(actor); err != nil {
+		return err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if actor != username {
+		if err := e.requireOwner(sub, actor); err != nil {
+			return err
+		}
+	}
+	if _, exists := sub.Moderators[username]; !exists {
+		return fmt.Errorf("%w: %s is not a moderator of %s", ErrNotModerator, username, subreddit)
+	}
+
+	delete(sub.Moderators, username)
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) ListModerators(viewer, subreddit string) ([]Moderator, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.checkCanView(sub, viewer); err != nil {
+		return nil, err
+	}
+
+	moderators := make([]Moderator, 0, len(sub.Moderators)+1)
+	for username, permissions := range sub.Moderators {
+		moderators = append(moderators, Moderator{Username: username, Permissions: permissions})
+	}
+	sort.Slice(moderators, func(i, j int) bool { return moderators[i].Username < moderators[j].Username })
+	if sub.Owner != "" {
+		moderators = append([]Moderator{{Username: sub.Owner, Permissions: PermAll, Owner: true}}, moderators...)
+	}
+	return moderators, nil
+}
+
+func (e *Engine) HasPermission(subreddit, username string, required ModPermission) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sub, exists := e.Subreddits[subreddit]
+	return exists && sub.permissions(username).Has(required)
+}
+
+func (e *Engine) UpdateSubreddit(actor, subreddit string, settings SubredditSettings) (SubredditInfo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(actor); err != nil {
+		return SubredditInfo{}, err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return SubredditInfo{}, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, actor, PermConfig); err != nil {
+		return SubredditInfo{}, err
+	}
+
+	sub.Description = settings.Description
+	e.metrics.IncrementOperation()
+	return sub.info(), nil
+}
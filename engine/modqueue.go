@@ -0,0 +1,312 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const RemovedMarker = "[removed]"
+
+type ModAction int
+
+const (
+	ModApprove ModAction = iota
+	ModRemove
+	ModIgnore
+)
+
+var modActionNames = map[ModAction]string{
+	ModApprove: "approve",
+	ModRemove:  "remove",
+	ModIgnore:  "ignore",
+}
+
+type Report struct {
+	Reporter string
+	Reason   string
+	Created  time.Time
+}
+
+type Moderation struct {
+	Removed       bool
+	Filtered      bool
+	IgnoreReports bool
+	Reports       []Report
+}
+
+type QueueItem struct {
+	Subreddit string
+	PostID    int
+	CommentID int
+	Author    string
+	Title     string
+	Content   string
+	Timestamp time.Time
+	Removed   bool
+	Filtered  bool
+	Reports   []Report
+}
+
+func (a ModAction) String() string {
+	if name, ok := modActionNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("ModAction(%d)", int(a))
+}
+
+func ParseModAction(name string) (ModAction, error) {
+	for action, actionName := range modActionNames {
+		if strings.EqualFold(name, actionName) {
+			return action, nil
+		}
+	}
+	return ModIgnore, fmt.Errorf("%w: %q", ErrInvalidModAction, name)
+}
+
+func (m *Moderation) needsReview() bool {
+	return m.Filtered || (len(m.Reports) > 0 && !m.IgnoreReports)
+}
+
+func (m *Moderation) report(reporter, reason string, now time.Time) error {
+	for _, report := range m.Reports {
+		if report.Reporter == reporter {
+			return fmt.Errorf("%w: by %s", ErrAlreadyReported, reporter)
+		}
+	}
+	m.Reports = append(m.Reports, Report{Reporter: reporter, Reason: reason, Created: now})
+	return nil
+}
+
+func (m *Moderation) apply(action ModAction) error {
+	switch action {
+	case ModApprove:
+		m.Removed = false
+		m.IgnoreReports = false
+	case ModRemove:
+		m.Removed = true
+	case ModIgnore:
+		m.IgnoreReports = true
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidModAction, action)
+	}
+	if action != ModIgnore {
+		m.Filtered = false
+	}
+	m.Reports = nil
+	return nil
+}
+
+func (sub *Subreddit) filters(texts ...string) bool {
+	for _, word := range sub.FilterWords {
+		for _, text := range texts {
+			if strings.Contains(strings.ToLower(text), word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *Engine) autoFilter(sub *Subreddit, author string, moderation *Moderation, texts ...string) {
+	if sub.IsModerator(author) || !sub.filters(texts...) {
+		return
+	}
+	moderation.Removed = true
+	moderation.Filtered = true
+}
+
+func (e *Engine) SetFilterWords(moderator, subreddit string, words []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(moderator); err != nil {
+		return err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, moderator, PermConfig); err != nil {
+		return err
+	}
+
+	filtered := []string{}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			filtered = append(filtered, word)
+		}
+	}
+	sub.FilterWords = filtered
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) reportTarget(reporter, reason string) error {
+	if err := e.requireConnected(reporter); err != nil {
+		return err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("%w: a reason is required", ErrInvalidReport)
+	}
+	return nil
+}
+
+func (e *Engine) ReportPost(reporter string, postID int, reason string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.reportTarget(reporter, reason); err != nil {
+		return err
+	}
+	post, _, err := e.viewPost(reporter, postID)
+	if err != nil {
+		return err
+	}
+	if post.Deleted {
+		return fmt.Errorf("%w: %d", ErrPostNotFound, postID)
+	}
+
+	if err := post.report(reporter, reason, time.Now()); err != nil {
+		return err
+	}
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) ReportComment(reporter string, commentID int, reason string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.reportTarget(reporter, reason); err != nil {
+		return err
+	}
+	comment, exists := e.comments[commentID]
+	if !exists || comment.Deleted || comment.Removed {
+		return fmt.Errorf("%w: %d", ErrCommentNotFound, commentID)
+	}
+	if _, _, err := e.viewPost(reporter, comment.PostID); err != nil {
+		return err
+	}
+
+	if err := comment.report(reporter, reason, time.Now()); err != nil {
+		return err
+	}
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) moderatorOf(moderator string, postID int) error {
+	if err := e.requireConnected(moderator); err != nil {
+		return err
+	}
+	post, exists := e.posts[postID]
+	if !exists {
+		return fmt.Errorf("%w: %d", ErrPostNotFound, postID)
+	}
+	sub, exists := e.Subreddits[post.Subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, post.Subreddit)
+	}
+	return e.requirePermission(sub, moderator, PermPosts)
+}
+
+func (e *Engine) ModeratePost(moderator string, postID int, action ModAction) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.moderatorOf(moderator, postID); err != nil {
+		return err
+	}
+	post, err := e.livePost(postID)
+	if err != nil {
+		return err
+	}
+
+	if err := post.apply(action); err != nil {
+		return err
+	}
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) ModerateComment(moderator string, commentID int, action ModAction) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	comment, err := e.liveComment(commentID)
+	if err != nil {
+		return err
+	}
+	if err := e.moderatorOf(moderator, comment.PostID); err != nil {
+		return err
+	}
+
+	if err := comment.apply(action); err != nil {
+		return err
+	}
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func queueComments(queue []QueueItem, subreddit string, comments []*Comment) []QueueItem {
+	for _, comment := range comments {
+		if !comment.Deleted && comment.needsReview() {
+			queue = append(queue, QueueItem{
+				Subreddit: subreddit,
+				PostID:    comment.PostID,
+				CommentID: comment.ID,
+				Author:    comment.Author,
+				Content:   comment.Content,
+				Timestamp: comment.Timestamp,
+				Removed:   comment.Removed,
+				Filtered:  comment.Filtered,
+				Reports:   append([]Report{}, comment.Reports...),
+			})
+		}
+		queue = queueComments(queue, subreddit, comment.Replies)
+	}
+	return queue
+}
+
+func (e *Engine) GetModQueue(moderator, subreddit string) ([]QueueItem, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, moderator, PermPosts); err != nil {
+		return nil, err
+	}
+
+	queue := []QueueItem{}
+	for _, post := range sub.Posts {
+		if !post.Deleted && post.needsReview() {
+			queue = append(queue, QueueItem{
+				Subreddit: subreddit,
+				PostID:    post.ID,
+				Author:    post.Author,
+				Title:     post.Title,
+				Content:   post.Content,
+				Timestamp: post.Timestamp,
+				Removed:   post.Removed,
+				Filtered:  post.Filtered,
+				Reports:   append([]Report{}, post.Reports...),
+			})
+		}
+		queue = queueComments(queue, subreddit, post.Comments)
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		if !queue[i].Timestamp.Equal(queue[j].Timestamp) {
+			return queue[i].Timestamp.Before(queue[j].Timestamp)
+		}
+		if queue[i].PostID != queue[j].PostID {
+			return queue[i].PostID < queue[j].PostID
+		}
+		return queue[i].CommentID < queue[j].CommentID
+	})
+	return queue, nil
+}
@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type PostKind int
+
+const (
+	KindText PostKind = iota
+	KindLink
+	KindImage
+)
+
+const (
+	MaxTitleLength = 300
+	MaxFlairLength = 64
+)
+
+var postKindNames = map[PostKind]string{
+	KindText:  "text",
+	KindLink:  "link",
+	KindImage: "image",
+}
+
+type PostDraft struct {
+	Title   string
+	Kind    PostKind
+	Content string
+	URL     string
+	NSFW    bool
+	Spoiler bool
+	Flair   string
+}
+
+type NSFWFilter int
+
+const (
+	NSFWInclude NSFWFilter = iota
+	NSFWExclude
+	NSFWOnly
+)
+
+var nsfwFilterNames = map[NSFWFilter]string{
+	NSFWInclude: "include",
+	NSFWExclude: "exclude",
+	NSFWOnly:    "only",
+}
+
+func (k PostKind) String() string {
+	if name, ok := postKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("PostKind(%d)", int(k))
+}
+
+func ParsePostKind(name string) (PostKind, error) {
+	if name == "" {
+		return KindText, nil
+	}
+	for kind, kindName := range postKindNames {
+		if strings.EqualFold(name, kindName) {
+			return kind, nil
+		}
+	}
+	return KindText, fmt.Errorf("%w: unknown kind %q", ErrInvalidPost, name)
+}
+
+func (f NSFWFilter) String() string {
+	if name, ok := nsfwFilterNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("NSFWFilter(%d)", int(f))
+}
+
+func (f NSFWFilter) Matches(post Post) bool {
+	switch f {
+	case NSFWExclude:
+		return !post.NSFW
+	case NSFWOnly:
+		return post.NSFW
+	default:
+		return true
+	}
+}
+
+func ParseNSFWFilter(name string) (NSFWFilter, error) {
+	for filter, filterName := range nsfwFilterNames {
+		if strings.EqualFold(name, filterName) {
+			return filter, nil
+		}
+	}
+	return NSFWInclude, fmt.Errorf("%w: %q", ErrInvalidFilter, name)
+}
+
+func titleFromContent(content string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	if runes := []rune(title); len(runes) > MaxTitleLength {
+		title = string(runes[:MaxTitleLength])
+	}
+	return title
+}
+
+func (d PostDraft) validate() error {
+	title := strings.TrimSpace(d.Title)
+	switch {
+	case title == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidPost)
+	case len([]rune(title)) > MaxTitleLength:
+		return fmt.Errorf("%w: title exceeds %d characters", ErrInvalidPost, MaxTitleLength)
+	case len([]rune(d.Flair)) > MaxFlairLength:
+		return fmt.Errorf("%w: flair exceeds %d characters", ErrInvalidPost, MaxFlairLength)
+	}
+
+	switch d.Kind {
+	case KindText:
+		if d.URL != "" {
+			return fmt.Errorf("%w: text posts cannot have a URL", ErrInvalidPost)
+		}
+	case KindLink, KindImage:
+		link, err := url.Parse(d.URL)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return fmt.Errorf("%w: %s posts need an http(s) URL", ErrInvalidPost, d.Kind)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %s", ErrInvalidPost, d.Kind)
+	}
+	return nil
+}
+
+func (e *Engine) SubmitPost(username, subreddit string, draft PostDraft) (int, error) {
+	return e.SubmitPostAt(username, subreddit, draft, time.Now())
+}
+
+func (e *Engine) SubmitPostAt(username, subreddit string, draft PostDraft, at time.Time) (int, error) {
+	if strings.TrimSpace(draft.Title) == "" {
+		draft.Title = titleFromContent(draft.Content)
+	}
+	if err := draft.validate(); err != nil {
+		return 0, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, userExists := e.Users[username]
+	if !userExists {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	sub, subExists := e.Subreddits[subreddit]
+	if !subExists {
+		return 0, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+
+	if !user.Connected {
+		return 0, fmt.Errorf("%w: %s", ErrUserNotConnected, username)
+	}
+	if err := e.checkCanContribute(sub, username); err != nil {
+		return 0, err
+	}
+	if err := e.checkCanSubmit(sub, username); err != nil {
+		return 0, err
+	}
+
+	post := &Post{
+		ID:        e.PostCount + 1,
+		Subreddit: subreddit,
+		Author:    username,
+		Title:     strings.TrimSpace(draft.Title),
+		Kind:      draft.Kind,
+		Content:   draft.Content,
+		URL:       draft.URL,
+		NSFW:      draft.NSFW,
+		Spoiler:   draft.Spoiler,
+		Flair:     draft.Flair,
+		Comments:  []*Comment{},
+		Timestamp: at,
+	}
+	e.autoFilter(sub, username, &post.Moderation, post.Title, post.Content, post.URL)
+	e.PostCount++
+	sub.Posts = append(sub.Posts, post)
+	e.posts[post.ID] = post
+	e.metrics.IncrementOperation()
+	return post.ID, nil
+}
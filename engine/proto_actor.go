@@ -11,7 +11,9 @@ type RegisterUserMessage struct {
 }
 
 type CreateSubredditMessage struct {
-	Name string
+	Name  string
+	Owner string
+	Type  SubredditType
 }
 
 type JoinSubredditMessage struct {
@@ -22,7 +24,13 @@ type JoinSubredditMessage struct {
 type PostMessage struct {
 	Username   string
 	Subreddit  string
+	Title      string
+	Kind       PostKind
 	Content    string
+	URL        string
+	NSFW       bool
+	Spoiler    bool
+	Flair      string
 	ResponseCh chan int
 }
 
@@ -45,7 +53,7 @@ type DownvoteMessage struct {
 
 type GetFeedMessage struct {
 	Subreddit  string
-	SortBy     string
+	SortBy     FeedSort
 	Limit      int
 	ResponseCh chan []Post
 }
@@ -70,7 +78,13 @@ func (state *EngineActor) Receive(ctx actor.Context) {
 		}
 
 	case *CreateSubredditMessage:
-		if err := state.engine.CreateSubreddit(msg.Name); err != nil {
+		var err error
+		if msg.Owner != "" {
+			err = state.engine.CreateSubredditAs(msg.Owner, msg.Name, msg.Type)
+		} else {
+			err = state.engine.CreateSubreddit(msg.Name)
+		}
+		if err != nil {
 			fmt.Printf("Error creating subreddit: %v\n", err)
 		} else {
 			fmt.Printf("Subreddit created: %s\n", msg.Name)
@@ -91,29 +105,34 @@ func (state *EngineActor) Receive(ctx actor.Context) {
 		}
 
 	case *UpvoteMessage:
-		if err := state.engine.UpvotePost(msg.Subreddit, msg.PostID); err != nil {
+		if err := state.engine.UpvotePost(msg.Username, msg.Subreddit, msg.PostID); err != nil {
 			fmt.Printf("Error upvoting post: %v\n", err)
 		} else {
 			fmt.Printf("User %s upvoted post ID %d in subreddit %s\n", msg.Username, msg.PostID, msg.Subreddit)
-			state.engine.UpdateAllUsersKarma()
 		}
 
 	case *DownvoteMessage:
-		if err := state.engine.DownvotePost(msg.Subreddit, msg.PostID); err != nil {
+		if err := state.engine.DownvotePost(msg.Username, msg.Subreddit, msg.PostID); err != nil {
 			fmt.Printf("Error downvoting post: %v\n", err)
 		} else {
 			fmt.Printf("User %s downvoted post ID %d in subreddit %s\n", msg.Username, msg.PostID, msg.Subreddit)
-			state.engine.UpdateAllUsersKarma()
 		}
 
 	case *PostMessage:
-		postID, err := state.engine.PostInSubreddit(msg.Username, msg.Subreddit, msg.Content)
+		postID, err := state.engine.SubmitPost(msg.Username, msg.Subreddit, PostDraft{
+			Title:   msg.Title,
+			Kind:    msg.Kind,
+			Content: msg.Content,
+			URL:     msg.URL,
+			NSFW:    msg.NSFW,
+			Spoiler: msg.Spoiler,
+			Flair:   msg.Flair,
+		})
 		if err != nil {
 			fmt.Printf("Error posting: %v\n", err)
 			msg.ResponseCh <- 0
 		} else {
 			fmt.Printf("Post created with ID: %d by user %s\n", postID, msg.Username)
-			state.engine.UpdateAllUsersKarma()
 			msg.ResponseCh <- postID
 		}
 
@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SubredditType int
+
+const (
+	TypePublic SubredditType = iota
+	TypeRestricted
+	TypePrivate
+)
+
+var subredditTypeNames = map[SubredditType]string{
+	TypePublic:     "public",
+	TypeRestricted: "restricted",
+	TypePrivate:    "private",
+}
+
+func (t SubredditType) String() string {
+	if name, ok := subredditTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("SubredditType(%d)", int(t))
+}
+
+func ParseSubredditType(name string) (SubredditType, error) {
+	if name == "" {
+		return TypePublic, nil
+	}
+	for kind, kindName := range subredditTypeNames {
+		if strings.EqualFold(name, kindName) {
+			return kind, nil
+		}
+	}
+	return TypePublic, fmt.Errorf("%w: %q", ErrInvalidSubredditType, name)
+}
+
+func (sub *Subreddit) canView(username string) bool {
+	if sub.Type != TypePrivate {
+		return true
+	}
+	_, member := sub.Members[username]
+	return member || sub.IsModerator(username)
+}
+
+func (sub *Subreddit) canSubmit(username string) bool {
+	switch sub.Type {
+	case TypeRestricted:
+		return sub.Approved[username] || sub.IsModerator(username)
+	case TypePrivate:
+		return sub.canView(username)
+	default:
+		return true
+	}
+}
+
+func (e *Engine) checkCanView(sub *Subreddit, username string) error {
+	if !sub.canView(username) {
+		return fmt.Errorf("%w: %s", ErrPrivateSubreddit, sub.Name)
+	}
+	return nil
+}
+
+func (e *Engine) checkCanSubmit(sub *Subreddit, username string) error {
+	if err := e.checkCanView(sub, username); err != nil {
+		return err
+	}
+	if !sub.canSubmit(username) {
+		return fmt.Errorf("%w: %s in %s", ErrNotApproved, username, sub.Name)
+	}
+	return nil
+}
+
+func (e *Engine) CheckSubredditAccess(viewer, subreddit string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	return e.checkCanView(sub, viewer)
+}
+
+func (e *Engine) CheckPostAccess(viewer string, postID int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, _, err := e.viewPost(viewer, postID)
+	return err
+}
+
+func (e *Engine) viewPost(viewer string, postID int) (*Post, bool, error) {
+	post, exists := e.posts[postID]
+	if !exists {
+		return nil, false, fmt.Errorf("%w: %d", ErrPostNotFound, postID)
+	}
+	sub, exists := e.Subreddits[post.Subreddit]
+	if !exists {
+		return post, false, nil
+	}
+	if err := e.checkCanView(sub, viewer); err != nil {
+		return nil, false, err
+	}
+	moderator := sub.IsModerator(viewer)
+	if post.Removed && !moderator {
+		return nil, false, fmt.Errorf("%w: %d", ErrPostNotFound, postID)
+	}
+	return post, moderator, nil
+}
+
+func (e *Engine) grant(moderator, subreddit, username string, pick func(*Subreddit) map[string]bool) error {
+	if err := e.requireConnected(moderator); err != nil {
+		return err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, moderator, PermUsers); err != nil {
+		return err
+	}
+	if _, exists := e.Users[username]; !exists {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	pick(sub)[username] = true
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func approved(sub *Subreddit) map[string]bool {
+	return sub.Approved
+}
+
+func invites(sub *Subreddit) map[string]bool {
+	return sub.Invites
+}
+
+func (e *Engine) ApproveUser(moderator, subreddit, username string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.grant(moderator, subreddit, username, approved)
+}
+
+func (e *Engine) UnapproveUser(moderator, subreddit, username string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.requireConnected(moderator); err != nil {
+		return err
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.requirePermission(sub, moderator, PermUsers); err != nil {
+		return err
+	}
+	if !sub.Approved[username] {
+		return fmt.Errorf("%w: %s in %s", ErrNotApproved, username, subreddit)
+	}
+
+	delete(sub.Approved, username)
+	e.metrics.IncrementOperation()
+	return nil
+}
+
+func (e *Engine) InviteUser(moderator, subreddit, username string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.grant(moderator, subreddit, username, invites)
+}
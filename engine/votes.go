@@ -0,0 +1,208 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+type VoteDirection int
+
+const (
+	VoteNone VoteDirection = 0
+	VoteUp   VoteDirection = 1
+	VoteDown VoteDirection = -1
+)
+
+type postVoteKey struct {
+	username string
+	postID   int
+}
+
+type commentVoteKey struct {
+	username  string
+	commentID int
+}
+
+func (e *Engine) VotePost(username, subreddit string, postID int, direction VoteDirection) (Post, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, userExists := e.Users[username]
+	if !userExists {
+		return Post{}, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	sub, subExists := e.Subreddits[subreddit]
+	if !subExists {
+		return Post{}, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+
+	if !user.Connected {
+		return Post{}, fmt.Errorf("%w: %s", ErrUserNotConnected, username)
+	}
+	if err := e.checkBanned(sub, username); err != nil {
+		return Post{}, err
+	}
+	if err := e.checkCanView(sub, username); err != nil {
+		return Post{}, err
+	}
+
+	post, err := e.postIn(sub, postID)
+	if err != nil {
+		return Post{}, err
+	}
+
+	key := postVoteKey{username: username, postID: postID}
+	previous := e.postVotes[key]
+	applyVote(&post.Upvotes, &post.Downvotes, previous, direction)
+	e.adjustKarma(post.Author, int(direction-previous), 0)
+	e.recordVote(postID, int(direction-previous), time.Now())
+	if direction == VoteNone {
+		delete(e.postVotes, key)
+	} else {
+		e.postVotes[key] = direction
+	}
+	e.metrics.IncrementOperation()
+	return *post, nil
+}
+
+func (e *Engine) UpvotePost(username, subreddit string, postID int) error {
+	post, err := e.VotePost(username, subreddit, postID, VoteUp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Post %d in subreddit %s upvoted by %s. Total upvotes: %d\n", postID, subreddit, username, post.Upvotes)
+	return nil
+}
+
+func (e *Engine) DownvotePost(username, subreddit string, postID int) error {
+	post, err := e.VotePost(username, subreddit, postID, VoteDown)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Post %d in subreddit %s downvoted by %s. Total downvotes: %d\n", postID, subreddit, username, post.Downvotes)
+	return nil
+}
+
+func (e *Engine) RetractVote(username, subreddit string, postID int) error {
+	_, err := e.VotePost(username, subreddit, postID, VoteNone)
+	return err
+}
+
+func (e *Engine) GetPostVote(username, subreddit string, postID int) (VoteDirection, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.Users[username]; !exists {
+		return VoteNone, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return VoteNone, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.checkCanView(sub, username); err != nil {
+		return VoteNone, err
+	}
+
+	if _, err := e.postIn(sub, postID); err != nil {
+		return VoteNone, err
+	}
+	return e.postVotes[postVoteKey{username: username, postID: postID}], nil
+}
+
+func (e *Engine) VoteComment(username, subreddit string, postID, commentID int, direction VoteDirection) (Comment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	user, userExists := e.Users[username]
+	if !userExists {
+		return Comment{}, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	sub, subExists := e.Subreddits[subreddit]
+	if !subExists {
+		return Comment{}, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+
+	if !user.Connected {
+		return Comment{}, fmt.Errorf("%w: %s", ErrUserNotConnected, username)
+	}
+	if err := e.checkBanned(sub, username); err != nil {
+		return Comment{}, err
+	}
+	if err := e.checkCanView(sub, username); err != nil {
+		return Comment{}, err
+	}
+
+	post, err := e.postIn(sub, postID)
+	if err != nil {
+		return Comment{}, err
+	}
+	comment, err := e.commentOn(post, commentID)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	key := commentVoteKey{username: username, commentID: commentID}
+	previous := e.commentVotes[key]
+	applyVote(&comment.Upvotes, &comment.Downvotes, previous, direction)
+	e.adjustKarma(comment.Author, 0, int(direction-previous))
+	if direction == VoteNone {
+		delete(e.commentVotes, key)
+	} else {
+		e.commentVotes[key] = direction
+	}
+	e.metrics.IncrementOperation()
+
+	voted := *comment
+	voted.Replies = nil
+	return voted, nil
+}
+
+func (e *Engine) UpvoteComment(username, subreddit string, postID, commentID int) error {
+	_, err := e.VoteComment(username, subreddit, postID, commentID, VoteUp)
+	return err
+}
+
+func (e *Engine) DownvoteComment(username, subreddit string, postID, commentID int) error {
+	_, err := e.VoteComment(username, subreddit, postID, commentID, VoteDown)
+	return err
+}
+
+func (e *Engine) GetCommentVote(username, subreddit string, postID, commentID int) (VoteDirection, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.Users[username]; !exists {
+		return VoteNone, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	sub, exists := e.Subreddits[subreddit]
+	if !exists {
+		return VoteNone, fmt.Errorf("%w: %s", ErrSubredditNotFound, subreddit)
+	}
+	if err := e.checkCanView(sub, username); err != nil {
+		return VoteNone, err
+	}
+
+	post, err := e.postIn(sub, postID)
+	if err != nil {
+		return VoteNone, err
+	}
+	if _, err := e.commentOn(post, commentID); err != nil {
+		return VoteNone, err
+	}
+	return e.commentVotes[commentVoteKey{username: username, commentID: commentID}], nil
+}
+
+func applyVote(upvotes, downvotes *int, previous, next VoteDirection) {
+	switch previous {
+	case VoteUp:
+		*upvotes--
+	case VoteDown:
+		*downvotes--
+	}
+	switch next {
+	case VoteUp:
+		*upvotes++
+	case VoteDown:
+		*downvotes++
+	}
+}
@@ -1,62 +1,186 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"project4/apis"
 	"project4/client"
 	"project4/client_rest"
 	"project4/engine"
 	"project4/performance"
 	"sync"
+	"syscall"
 	"time"
 )
 
 func simulateClients(wg *sync.WaitGroup, id int) {
 	defer wg.Done()
 
-	privateKey, publicKey := client_rest.GenerateKeys()
-	username := fmt.Sprintf("user%d", id)
+	privateKey, _ := client_rest.GenerateKeys()
+	username := fmt.Sprintf("rest_user%d", id)
 
-	client_rest.RegisterUser(username, publicKey)
+	client_rest.RegisterUser(username, privateKey)
+	if _, err := client_rest.Login(username, privateKey); err != nil {
+		fmt.Println("Error logging in:", err)
+	}
 
 	postContent := fmt.Sprintf("Hello from %s!", username)
-	client_rest.CreatePost(username, postContent, privateKey)
+	client_rest.CreatePost(username, "general", postContent, privateKey)
 
 	client_rest.FetchPosts()
 
 	client_rest.FetchUserPublicKey(username)
+	client_rest.Logout(username)
 
 	fmt.Printf("Client %d simulation complete.\n", id)
 }
 
 func main() {
 	engineInstance := engine.NewEngine()
+	metrics := performance.StartMetrics()
+	engineInstance.SetMetrics(metrics)
+	engineInstance.CreateSubreddit("general")
+
+	server := apis.NewServer(apis.Config{
+		Addr:         ":8080",
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		Engine:       engineInstance,
+	})
+
+	serverErr := make(chan error, 1)
 	go func() {
 		fmt.Println("Starting the Engine and REST API Server...")
-		apis.StartAPIServer()
+		serverErr <- server.Start()
 	}()
 
-	time.Sleep(2 * time.Second)
+	select {
+	case <-server.Ready():
+	case err := <-serverErr:
+		log.Fatalf("Error starting server: %v", err)
+	}
 
-	metrics := performance.StartMetrics()
-	engineInstance.SetMetrics(metrics)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	simulationDone := make(chan struct{})
+	go func() {
+		runSimulation(engineInstance)
+		close(simulationDone)
+	}()
+
+	select {
+	case <-simulationDone:
+	case <-ctx.Done():
+		log.Println("Interrupted, shutting down...")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	if err := <-serverErr; err != nil {
+		log.Printf("Server stopped with error: %v", err)
+	}
+
+	metrics.Stop()
+	metrics.Report()
+
+	log.Println("Simulation and demonstration complete!")
+}
+
+func runSimulation(engineInstance *engine.Engine) {
 	log.Println("Initializing Reddit Clone Simulation...")
 
-	privateKeyAlice, publicKeyAlice := client_rest.GenerateKeys()
-	privateKeyBob, publicKeyBob := client_rest.GenerateKeys()
+	privateKeyAlice, _ := client_rest.GenerateKeys()
+	privateKeyBob, _ := client_rest.GenerateKeys()
 
 	fmt.Println("==== Using REST Client with RSA Signatures ====")
-	client_rest.RegisterUser("Alice", publicKeyAlice)
-	client_rest.RegisterUser("Bob", publicKeyBob)
+	client_rest.RegisterUser("Alice", privateKeyAlice)
+	client_rest.RegisterUser("Bob", privateKeyBob)
+	if _, err := client_rest.Login("Alice", privateKeyAlice); err != nil {
+		fmt.Println("Error logging in:", err)
+	}
+	if _, err := client_rest.Login("Bob", privateKeyBob); err != nil {
+		fmt.Println("Error logging in:", err)
+	}
 
-	client_rest.CreatePost("Alice", "Hello World! My first post.", privateKeyAlice)
-	client_rest.CreatePost("Bob", "Go is awesome!", privateKeyBob)
+	client_rest.CreateSubreddit("Alice", "golang_rest", privateKeyAlice)
+	client_rest.JoinSubreddit("Alice", "golang_rest", privateKeyAlice)
+	client_rest.JoinSubreddit("Bob", "golang_rest", privateKeyBob)
+	client_rest.FetchSubreddits()
+	client_rest.FetchSubredditMembers("golang_rest")
+	client_rest.AddModerator("Alice", "golang_rest", "Bob", []string{"posts"}, privateKeyAlice)
+	client_rest.UpdateSubreddit("Bob", "golang_rest", "Bob cannot change this", privateKeyBob)
+	client_rest.UpdateSubreddit("Alice", "golang_rest", "Go discussion over REST", privateKeyAlice)
+	client_rest.FetchModerators("golang_rest")
+	client_rest.BanUser("Bob", "golang_rest", "Alice", "coup attempt", "", privateKeyBob)
+	client_rest.RemoveModerator("Alice", "golang_rest", "Bob", privateKeyAlice)
+	client_rest.MuteUser("Alice", "golang_rest", "Bob", "cool off", "10m", privateKeyAlice)
+	client_rest.FetchBans("Alice", "golang_rest", privateKeyAlice)
+	client_rest.UnmuteUser("Alice", "golang_rest", "Bob", privateKeyAlice)
+
+	client_rest.CreatePost("Alice", "general", "Hello World! My first post.", privateKeyAlice)
+	client_rest.CreatePost("Bob", "general", "Go is awesome!", privateKeyBob)
+	client_rest.SubmitPost("Alice", client_rest.Post{
+		Subreddit: "general",
+		Title:     "The Go memory model",
+		Kind:      "link",
+		URL:       "https://go.dev/ref/mem",
+		Flair:     "Discussion",
+	}, privateKeyAlice)
+
+	client_rest.CommentOnPost("Bob", "general", 1, "Welcome, Alice!", privateKeyBob)
+	client_rest.ReplyToComment("Alice", "general", 1, 1, "Thanks, Bob!", privateKeyAlice)
+	client_rest.VoteComment("Alice", "general", 1, 1, "up", privateKeyAlice)
+	client_rest.FetchComments("general", 1)
+	client_rest.EditComment("Bob", 1, "Welcome aboard, Alice!", privateKeyBob)
+	client_rest.FetchPost(1)
+	client_rest.FetchCommentTree(1, "best", 2, 50)
+	client_rest.FetchComment(2)
+	client_rest.VotePost("Bob", "general", 1, "up", privateKeyBob)
+	client_rest.FetchMyVote("Bob", "general", 1, privateKeyBob)
+	client_rest.VotePost("Alice", "general", 2, "down", privateKeyAlice)
+	client_rest.VotePost("Alice", "general", 2, "clear", privateKeyAlice)
+	client_rest.SendMessage("Alice", "Bob", "Thanks for the welcome!", privateKeyAlice)
+	client_rest.ReplyToMessage("Bob", 1, "Any time!", privateKeyBob)
+	client_rest.FetchInbox("Alice", privateKeyAlice)
+	client_rest.FetchHomeFeed("Bob", "hot", "", 10, privateKeyBob)
 
 	client_rest.FetchPosts()
+	client_rest.FetchFeed("general", "top", "week", "", 10)
+	client_rest.FetchFilteredFeed("general", "Discussion", "exclude", 10)
+
+	client_rest.CreateSubredditOfType("Alice", "gophers_only", "private", privateKeyAlice)
+	client_rest.JoinSubreddit("Bob", "gophers_only", privateKeyBob)
+	client_rest.InviteUser("Alice", "gophers_only", "Bob", privateKeyAlice)
+	client_rest.JoinSubreddit("Bob", "gophers_only", privateKeyBob)
+	client_rest.CreateSubredditOfType("Alice", "announcements", "restricted", privateKeyAlice)
+	client_rest.CreatePost("Bob", "announcements", "Can I post here?", privateKeyBob)
+	client_rest.ApproveUser("Alice", "announcements", "Bob", privateKeyAlice)
+	client_rest.CreatePost("Bob", "announcements", "Approved to post!", privateKeyBob)
+	client_rest.FetchFeedAs("Bob", "gophers_only", 10, privateKeyBob)
+
+	client_rest.CreatePost("Alice", "golang_rest", "Generics tips and tricks", privateKeyAlice)
+	client_rest.ReportPost("Bob", 5, "off-topic", privateKeyBob)
+	client_rest.SetFilterWords("Alice", "golang_rest", []string{"spam"}, privateKeyAlice)
+	client_rest.CreatePost("Bob", "golang_rest", "Buy cheap spam here", privateKeyBob)
+	client_rest.FetchModQueue("Alice", "golang_rest", privateKeyAlice)
+	client_rest.ModeratePost("Alice", 5, "ignore", privateKeyAlice)
+	client_rest.ModeratePost("Alice", 6, "remove", privateKeyAlice)
+	client_rest.FetchFeed("golang_rest", "new", "", "", 10)
+
 	client_rest.FetchUserPublicKey("Alice")
 	client_rest.FetchUserPublicKey("Bob")
 
+	rotatedKeyAlice, _ := client_rest.GenerateKeys()
+	client_rest.RotateKey("Alice", privateKeyAlice, rotatedKeyAlice)
+	client_rest.FetchUserPublicKey("Alice")
+
 	user1 := client.NewClient("user1", engineInstance)
 	user2 := client.NewClient("user2", engineInstance)
 	user3 := client.NewClient("user3", engineInstance)
@@ -111,13 +235,11 @@ func main() {
 
 	log.Println("===== USER KARMA =====")
 	for _, username := range []string{"user1", "user2", "user3"} {
-		karma := engineInstance.ComputeKarma(username)
-		log.Printf("Karma for %s: %d\n", username, karma)
+		karma, _ := engineInstance.GetKarma(username)
+		log.Printf("Karma for %s: %d (post %d, comment %d)\n", username, karma.Total(), karma.Post, karma.Comment)
+	}
+	if mismatched := engineInstance.VerifyKarma(); len(mismatched) > 0 {
+		log.Printf("Karma drift detected: %v\n", mismatched)
 	}
 	log.Println("======================")
-
-	metrics.Stop()
-	metrics.Report()
-
-	log.Println("Simulation and demonstration complete!")
 }
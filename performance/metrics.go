@@ -21,6 +21,9 @@ func StartMetrics() *Metrics {
 }
 
 func (m *Metrics) IncrementOperation() {
+	if m == nil {
+		return
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.Operations++
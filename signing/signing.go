@@ -0,0 +1,91 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	HeaderUser      = "X-Reddit-User"
+	HeaderTimestamp = "X-Reddit-Timestamp"
+	HeaderNonce     = "X-Reddit-Nonce"
+	HeaderSignature = "X-Reddit-Signature"
+)
+
+func BodyHash(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+func CanonicalRequest(method, requestURI string, body []byte, timestamp, nonce string) string {
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		BodyHash(body),
+		timestamp,
+		nonce,
+	}, "\n")
+}
+
+func NewNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+func Sign(privateKey *rsa.PrivateKey, message string) (string, error) {
+	hash := sha256.Sum256([]byte(message))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func Verify(publicKey *rsa.PublicKey, message, signature string) error {
+	sigBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte(message))
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], sigBytes)
+}
+
+func RegistrationMessage(username string) string {
+	return strings.Join([]string{"REGISTER", username}, "\n")
+}
+
+func RotationMessage(username, oldFingerprint string) string {
+	return strings.Join([]string{"ROTATE", username, oldFingerprint}, "\n")
+}
+
+func KeyFingerprint(publicKey *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func LoginMessage(username, challenge string) string {
+	return strings.Join([]string{"LOGIN", username, challenge}, "\n")
+}
+
+func NewToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,1295 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"project4/apis"
+	"project4/client_rest"
+	"project4/engine"
+	"project4/signing"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postJSON(t *testing.T, url string, payload interface{}) *http.Response {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp
+}
+
+func sendSigned(t *testing.T, method, url string, payload interface{}, username string, key *rsa.PrivateKey) *http.Response {
+	t.Helper()
+	req, err := client_rest.NewSignedRequest(method, url, payload, username, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp
+}
+
+func fetchFeed(t *testing.T, url string) apis.FeedResponse {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected feed %s to succeed, got %d", url, resp.StatusCode)
+	}
+	var feed apis.FeedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return feed
+}
+
+func registerAPIUser(t *testing.T, baseURL, username string) *rsa.PrivateKey {
+	t.Helper()
+	privateKey, _ := client_rest.GenerateKeys()
+	registration, err := client_rest.NewRegistration(username, privateKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp := postJSON(t, baseURL+"/register", registration)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected registration of %s to succeed, got %d", username, resp.StatusCode)
+	}
+	loginAPIUser(t, baseURL, username, privateKey)
+	return privateKey
+}
+
+func loginAPIUser(t *testing.T, baseURL, username string, key *rsa.PrivateKey) string {
+	t.Helper()
+	resp := postJSON(t, baseURL+"/login/challenge", apis.LoginChallengeRequest{Username: username})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected login challenge for %s to succeed, got %d", username, resp.StatusCode)
+	}
+	var challenge struct {
+		Challenge string `json:"challenge"`
+	}
+	json.NewDecoder(resp.Body).Decode(&challenge)
+
+	signature := client_rest.SignMessage(key, signing.LoginMessage(username, challenge.Challenge))
+	resp = postJSON(t, baseURL+"/login", apis.LoginRequest{Username: username, Challenge: challenge.Challenge, Signature: signature})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected login of %s to succeed, got %d", username, resp.StatusCode)
+	}
+	var session struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(resp.Body).Decode(&session)
+	return session.Token
+}
+
+func sendWithToken(t *testing.T, method, url string, payload interface{}, token string) *http.Response {
+	t.Helper()
+	req, err := client_rest.NewSessionRequest(method, url, payload, token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp
+}
+
+func TestAPIPostReachesEngine(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	privateKey := registerAPIUser(t, server.URL, "alice")
+
+	content := "Hello from HTTP"
+	resp := sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Content: content}, "alice", privateKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected post to succeed, got %d", resp.StatusCode)
+	}
+
+	posts, err := e.GetFeed("general", engine.SortNew, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(posts) != 1 || posts[0].Author != "alice" || posts[0].Content != content {
+		t.Errorf("expected alice's post in the engine feed, got %+v", posts)
+	}
+
+	resp = postJSON(t, server.URL+"/post", client_rest.Post{Subreddit: "general", Content: "unsigned"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unsigned post to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPISubredditMembership(t *testing.T) {
+	e := engine.NewEngine()
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+
+	resp := sendSigned(t, "POST", server.URL+"/r/golang", nil, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected subreddit creation to succeed, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/join", nil, "bob", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected join signed with another user's key to fail, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/join", nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected join to succeed, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(server.URL + "/r/golang/members")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var members struct {
+		Members []string `json:"members"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&members); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(members.Members) != 1 || members.Members[0] != "bob" {
+		t.Errorf("expected bob to be the only member, got %v", members.Members)
+	}
+
+	resp, err = http.Get(server.URL + "/r/missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown subreddit, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIThreadedComments(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+	postID, _ := e.PostInSubreddit("alice", "general", "Discuss")
+
+	commentsURL := fmt.Sprintf("%s/r/general/posts/%d/comments", server.URL, postID)
+	resp := sendSigned(t, "POST", commentsURL, client_rest.Comment{Content: "First!"}, "bob", bobKey)
+	var created struct {
+		ID int `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected comment to be created, got %d", resp.StatusCode)
+	}
+
+	repliesURL := fmt.Sprintf("%s/%d/replies", commentsURL, created.ID)
+	resp = sendSigned(t, "POST", repliesURL, client_rest.Comment{Content: "Welcome!"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected reply to be created, got %d", resp.StatusCode)
+	}
+
+	voteURL := fmt.Sprintf("%s/%d/vote", commentsURL, created.ID)
+	resp = sendSigned(t, "POST", voteURL, client_rest.Vote{Direction: "up"}, "alice", aliceKey)
+	var vote apis.VoteResponse
+	json.NewDecoder(resp.Body).Decode(&vote)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || vote.Score != 1 || vote.Author != "bob" || vote.AuthorKarma != 1 {
+		t.Errorf("unexpected comment vote response %d: %+v", resp.StatusCode, vote)
+	}
+
+	resp, err := http.Get(commentsURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var comments []apis.CommentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&comments); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(comments) != 1 || len(comments[0].Replies) != 1 {
+		t.Fatalf("expected one comment with one reply, got %+v", comments)
+	}
+	if comments[0].Score != 1 {
+		t.Errorf("expected comment score of 1, got %d", comments[0].Score)
+	}
+
+	reply := comments[0].Replies[0]
+	resp, err = http.Get(fmt.Sprintf("%s/comments/%d", server.URL, reply.ID))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var byID apis.CommentResponse
+	json.NewDecoder(resp.Body).Decode(&byID)
+	if byID.PostID != postID || byID.ParentID != created.ID || byID.Content != "Welcome!" {
+		t.Errorf("unexpected comment fetched by ID: %+v", byID)
+	}
+	if comments[0].Replies[0].Author != "alice" || comments[0].Replies[0].Content != "Welcome!" {
+		t.Errorf("unexpected reply: %+v", comments[0].Replies[0])
+	}
+}
+
+func TestAPIVotePost(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+	postID, _ := e.PostInSubreddit("alice", "general", "Vote on me")
+
+	voteURL := fmt.Sprintf("%s/r/general/posts/%d/vote", server.URL, postID)
+	resp := sendSigned(t, "POST", voteURL, client_rest.Vote{Direction: "up"}, "bob", bobKey)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected vote to succeed, got %d", resp.StatusCode)
+	}
+	var result apis.VoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Score != 1 || result.Author != "alice" || result.AuthorKarma != 1 {
+		t.Errorf("unexpected vote response: %+v", result)
+	}
+
+	req, _ := client_rest.NewSignedRequest("POST", voteURL, client_rest.Vote{Direction: "up"}, "bob", bobKey)
+	tamperedBody := `{"direction":"down"}`
+	req.Body = io.NopCloser(bytes.NewBufferString(tamperedBody))
+	req.ContentLength = int64(len(tamperedBody))
+	req.GetBody = nil
+	tampered, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tampered.Body.Close()
+	if tampered.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected vote with tampered body to fail, got %d", tampered.StatusCode)
+	}
+
+	mine := sendSigned(t, "GET", voteURL, nil, "bob", bobKey)
+	defer mine.Body.Close()
+	var current struct {
+		Direction string `json:"direction"`
+	}
+	json.NewDecoder(mine.Body).Decode(&current)
+	if mine.StatusCode != http.StatusOK || current.Direction != "up" {
+		t.Errorf("expected bob's recorded vote to be up, got %d %q", mine.StatusCode, current.Direction)
+	}
+
+	resp = sendSigned(t, "POST", voteURL, client_rest.Vote{Direction: "clear"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected clearing the vote to succeed, got %d", resp.StatusCode)
+	}
+	cleared := sendSigned(t, "GET", voteURL, nil, "bob", bobKey)
+	defer cleared.Body.Close()
+	json.NewDecoder(cleared.Body).Decode(&current)
+	if current.Direction != "none" {
+		t.Errorf("expected a cleared vote to be reported as none, got %q", current.Direction)
+	}
+}
+
+func TestAPIDirectMessages(t *testing.T) {
+	e := engine.NewEngine()
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+
+	message := client_rest.DirectMessage{Receiver: "bob", Content: "hi"}
+	forged := sendSigned(t, "POST", server.URL+"/messages", message, "alice", bobKey)
+	forged.Body.Close()
+	if forged.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected message signed by another user to fail, got %d", forged.StatusCode)
+	}
+
+	resp := sendSigned(t, "POST", server.URL+"/messages", message, "alice", aliceKey)
+	var sent struct {
+		ID int `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&sent)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected message to be sent, got %d", resp.StatusCode)
+	}
+
+	replyURL := fmt.Sprintf("%s/messages/%d/reply", server.URL, sent.ID)
+	resp = sendSigned(t, "POST", replyURL, client_rest.DirectMessage{Content: "hello"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected reply to be sent, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "GET", server.URL+"/users/alice/inbox", nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected reading another user's inbox to fail, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "GET", server.URL+"/users/alice/inbox", nil, "alice", aliceKey)
+	defer resp.Body.Close()
+	var inbox []apis.MessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&inbox); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inbox) != 1 || inbox[0].Sender != "bob" || inbox[0].ParentID != sent.ID {
+		t.Errorf("expected bob's reply in alice's inbox, got %+v", inbox)
+	}
+}
+
+func TestServerStartAndShutdown(t *testing.T) {
+	server := apis.NewServer(apis.Config{Addr: "127.0.0.1:0", SessionTTL: -time.Minute, Engine: engine.NewEngine()})
+
+	done := make(chan error, 1)
+	go func() { done <- server.Start() }()
+
+	select {
+	case <-server.Ready():
+	case err := <-done:
+		t.Fatalf("server failed to start: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not become ready")
+	}
+
+	resp, err := http.Get("http://" + server.Addr() + "/r")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from running server, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("expected Start to return cleanly after shutdown, got %v", err)
+	}
+}
+
+func TestAPIPublicKeyFormats(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	resp := postJSON(t, server.URL+"/register", client_rest.User{Username: "mallory", PublicKey: "not a key"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected invalid key to be rejected, got %d", resp.StatusCode)
+	}
+
+	legacyKey, _ := client_rest.GenerateKeys()
+	resp = postJSON(t, server.URL+"/register", client_rest.User{
+		Username:  "legacy",
+		PublicKey: client_rest.EncodeLegacyPublicKey(&legacyKey.PublicKey),
+		Signature: client_rest.SignMessage(legacyKey, signing.RegistrationMessage("legacy")),
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected legacy modulus key to be accepted, got %d", resp.StatusCode)
+	}
+	loginAPIUser(t, server.URL, "legacy", legacyKey)
+
+	post := client_rest.Post{Subreddit: "general", Content: "still works"}
+	resp = sendSigned(t, "POST", server.URL+"/post", post, "legacy", legacyKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected legacy key signature to verify, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(server.URL + "/user/legacy/publickey")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var key struct {
+		PublicKey string `json:"public_key"`
+	}
+	json.NewDecoder(resp.Body).Decode(&key)
+	expected, _ := client_rest.EncodePublicKey(&legacyKey.PublicKey)
+	if key.PublicKey != expected {
+		t.Errorf("expected legacy key to be returned as PEM, got %q", key.PublicKey)
+	}
+}
+
+func TestAPIRejectsReplayedAndStaleRequests(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+
+	body, _ := json.Marshal(client_rest.Post{Subreddit: "general", Content: "once"})
+	req, _ := http.NewRequest("POST", server.URL+"/post", bytes.NewReader(body))
+	if err := client_rest.SignRequest(req, body, "alice", aliceKey); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected first request to succeed, got %d", resp.StatusCode)
+	}
+
+	replay, _ := http.NewRequest("POST", server.URL+"/post", bytes.NewReader(body))
+	replay.Header = req.Header.Clone()
+	resp, err = http.DefaultClient.Do(replay)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected replayed request to be rejected, got %d", resp.StatusCode)
+	}
+
+	stale, _ := http.NewRequest("POST", server.URL+"/post", bytes.NewReader(body))
+	timestamp := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	nonce, _ := signing.NewNonce()
+	message := signing.CanonicalRequest("POST", "/post", body, timestamp, nonce)
+	stale.Header.Set(signing.HeaderUser, "alice")
+	stale.Header.Set(signing.HeaderTimestamp, timestamp)
+	stale.Header.Set(signing.HeaderNonce, nonce)
+	stale.Header.Set(signing.HeaderSignature, client_rest.SignMessage(aliceKey, message))
+	resp, err = http.DefaultClient.Do(stale)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected stale request to be rejected, got %d", resp.StatusCode)
+	}
+
+	posts, _ := e.GetFeed("general", engine.SortNew, 10)
+	if len(posts) != 1 {
+		t.Errorf("expected exactly one post to be created, got %d", len(posts))
+	}
+}
+
+func TestAPILoginSessions(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	srv := apis.NewServer(apis.Config{Engine: e})
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	privateKey, _ := client_rest.GenerateKeys()
+	registration, _ := client_rest.NewRegistration("alice", privateKey)
+	resp := postJSON(t, server.URL+"/register", registration)
+	resp.Body.Close()
+	if e.IsConnected("alice") {
+		t.Fatal("expected registration alone not to connect the user")
+	}
+
+	post := client_rest.Post{Subreddit: "general", Content: "signed without a session"}
+	resp = sendSigned(t, "POST", server.URL+"/post", post, "alice", privateKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected a signature alone to authenticate a write, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "GET", server.URL+"/users/alice/inbox", nil, "alice", privateKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected signed reads to work without a session, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, server.URL+"/login", apis.LoginRequest{Username: "alice", Challenge: "made-up", Signature: "bogus"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected login without a challenge to fail, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, server.URL+"/login/challenge", apis.LoginChallengeRequest{Username: "alice"})
+	var pending struct {
+		Challenge string `json:"challenge"`
+	}
+	json.NewDecoder(resp.Body).Decode(&pending)
+	resp.Body.Close()
+	for i := 0; i < 3; i++ {
+		resp = postJSON(t, server.URL+"/login/challenge", apis.LoginChallengeRequest{Username: "alice"})
+		resp.Body.Close()
+	}
+	signature := client_rest.SignMessage(privateKey, signing.LoginMessage("alice", pending.Challenge))
+	resp = postJSON(t, server.URL+"/login", apis.LoginRequest{Username: "alice", Challenge: pending.Challenge, Signature: signature})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected later challenges not to invalidate a pending one, got %d", resp.StatusCode)
+	}
+	resp = postJSON(t, server.URL+"/login", apis.LoginRequest{Username: "alice", Challenge: pending.Challenge, Signature: signature})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a challenge to be single use, got %d", resp.StatusCode)
+	}
+
+	token := loginAPIUser(t, server.URL, "alice", privateKey)
+	if !e.IsConnected("alice") {
+		t.Fatal("expected login to connect the user")
+	}
+
+	resp = sendWithToken(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Content: "via token"}, token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected post with session token to succeed, got %d", resp.StatusCode)
+	}
+
+	resp = sendWithToken(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Content: "forged"}, "not-a-token")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unknown token to be rejected, got %d", resp.StatusCode)
+	}
+
+	srv.ExpireSessions(time.Now().Add(apis.DefaultSessionTTL + time.Minute))
+	if e.IsConnected("alice") {
+		t.Error("expected session expiry to disconnect the user")
+	}
+	resp = sendWithToken(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Content: "expired"}, token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected expired token to be rejected, got %d", resp.StatusCode)
+	}
+
+	other := loginAPIUser(t, server.URL, "alice", privateKey)
+	token = loginAPIUser(t, server.URL, "alice", privateKey)
+	resp = sendWithToken(t, "POST", server.URL+"/logout", nil, other)
+	resp.Body.Close()
+	if !e.IsConnected("alice") {
+		t.Error("expected the user to stay connected while another session is live")
+	}
+	resp = sendWithToken(t, "POST", server.URL+"/logout", nil, token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected logout to succeed, got %d", resp.StatusCode)
+	}
+	if e.IsConnected("alice") {
+		t.Error("expected logout to disconnect the user")
+	}
+
+	posts, _ := e.GetFeed("general", engine.SortNew, 10)
+	if len(posts) != 2 {
+		t.Errorf("expected only the signed and token-authenticated posts to be created, got %d", len(posts))
+	}
+}
+
+func TestAPIRegistrationPreventsTakeover(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+
+	attackerKey, attackerPublicKey := client_rest.GenerateKeys()
+	takeover, _ := client_rest.NewRegistration("alice", attackerKey)
+	resp := postJSON(t, server.URL+"/register", takeover)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected re-registration of alice to conflict, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, server.URL+"/register", client_rest.User{Username: "mallory", PublicKey: attackerPublicKey})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected registration without proof of possession to fail, got %d", resp.StatusCode)
+	}
+
+	_, otherPublicKey := client_rest.GenerateKeys()
+	resp = postJSON(t, server.URL+"/register", client_rest.User{
+		Username:  "mallory",
+		PublicKey: otherPublicKey,
+		Signature: client_rest.SignMessage(attackerKey, signing.RegistrationMessage("mallory")),
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected proof signed by a different key to fail, got %d", resp.StatusCode)
+	}
+
+	for _, name := range []string{"", "al", "has space", "../admin", "waytoolongusername_12345"} {
+		registration, _ := client_rest.NewRegistration(name, attackerKey)
+		resp = postJSON(t, server.URL+"/register", registration)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected username %q to be rejected, got %d", name, resp.StatusCode)
+		}
+	}
+
+	post := client_rest.Post{Subreddit: "general", Content: "impersonation"}
+	resp = sendSigned(t, "POST", server.URL+"/post", post, "alice", attackerKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected attacker's key to be rejected for alice, got %d", resp.StatusCode)
+	}
+
+	rotation, _ := client_rest.NewRegistration("alice", attackerKey)
+	resp = sendSigned(t, "PUT", server.URL+"/user/alice/publickey", rotation, "alice", attackerKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected rotation signed by a foreign key to fail, got %d", resp.StatusCode)
+	}
+
+	bobKey := registerAPIUser(t, server.URL, "bob")
+	resp = sendSigned(t, "PUT", server.URL+"/user/alice/publickey", rotation, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected rotation of another user's key to be forbidden, got %d", resp.StatusCode)
+	}
+
+	oldToken := loginAPIUser(t, server.URL, "alice", aliceKey)
+	newKey, _ := client_rest.GenerateKeys()
+	replayed, _ := client_rest.NewRegistration("alice", newKey)
+	resp = sendSigned(t, "PUT", server.URL+"/user/alice/publickey", replayed, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a registration proof to be rejected for rotation, got %d", resp.StatusCode)
+	}
+
+	crossed, _ := client_rest.NewKeyRotation("carol", aliceKey, newKey)
+	resp = postJSON(t, server.URL+"/register", crossed)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a rotation proof to be rejected for registration, got %d", resp.StatusCode)
+	}
+
+	rotation, _ = client_rest.NewKeyRotation("alice", aliceKey, newKey)
+	resp = sendSigned(t, "PUT", server.URL+"/user/alice/publickey", rotation, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected rotation signed by the current key to succeed, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/post", post, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the old key to stop working after rotation, got %d", resp.StatusCode)
+	}
+	resp = sendWithToken(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Content: "old session"}, oldToken)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected rotation to revoke existing sessions, got %d", resp.StatusCode)
+	}
+	if e.IsConnected("alice") {
+		t.Error("expected rotation to disconnect the user")
+	}
+
+	loginAPIUser(t, server.URL, "alice", newKey)
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Content: "new key"}, "alice", newKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the rotated key to work, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIFeedSortAndWindow(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	e.RegisterUser("author")
+	e.RegisterUser("voter")
+	e.ConnectUser("author")
+	e.ConnectUser("voter")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	old, _ := e.PostInSubredditAt("author", "general", "old favourite", time.Now().Add(-30*24*time.Hour))
+	recent, _ := e.PostInSubreddit("author", "general", "recent")
+	e.UpvotePost("voter", "general", old)
+
+	fetch := func(query string) []apis.PostResponse {
+		t.Helper()
+		return fetchFeed(t, server.URL+"/posts?"+query).Posts
+	}
+
+	if posts := fetch("subreddit=general&sort=top&t=all"); len(posts) != 2 || posts[0].ID != old {
+		t.Errorf("expected the old post to top the all-time feed, got %+v", posts)
+	}
+	if posts := fetch("subreddit=general&sort=top&t=week"); len(posts) != 1 || posts[0].ID != recent {
+		t.Errorf("expected only the recent post in this week's feed, got %+v", posts)
+	}
+	if posts := fetch("sort=new&limit=1"); len(posts) != 1 || posts[0].ID != recent {
+		t.Errorf("expected the newest post across subreddits, got %+v", posts)
+	}
+
+	first := fetchFeed(t, server.URL+"/posts?subreddit=general&sort=new&limit=1")
+	if first.Total != 2 || first.After == "" {
+		t.Fatalf("expected a first page with a next cursor, got %+v", first)
+	}
+	second := fetchFeed(t, server.URL+"/posts?subreddit=general&sort=new&limit=1&after="+first.After)
+	if len(second.Posts) != 1 || second.Posts[0].ID != old || second.After != "" || second.Before == "" {
+		t.Errorf("expected the second page to hold the old post, got %+v", second)
+	}
+
+	for _, query := range []string{"sort=best", "t=decade", "limit=0", "after=bogus"} {
+		resp, err := http.Get(server.URL + "/posts?" + query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected %q to be rejected, got %d", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestAPIHomeFeed(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("golang")
+	e.CreateSubreddit("movies")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+	e.JoinSubreddit("alice", "golang")
+	goPost, _ := e.PostInSubreddit("bob", "golang", "generics")
+	e.PostInSubreddit("bob", "movies", "sequels")
+
+	resp := sendSigned(t, "GET", server.URL+"/users/alice/home?sort=new", nil, "alice", aliceKey)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected home feed to succeed, got %d", resp.StatusCode)
+	}
+	var feed apis.FeedResponse
+	json.NewDecoder(resp.Body).Decode(&feed)
+	if feed.Total != 1 || len(feed.Posts) != 1 || feed.Posts[0].ID != goPost {
+		t.Errorf("expected only the subscribed golang post, got %+v", feed)
+	}
+
+	resp = sendSigned(t, "GET", server.URL+"/users/alice/home", nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected reading another user's home feed to be forbidden, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPICommentTree(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	e.RegisterUser("author")
+	e.ConnectUser("author")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	postID, _ := e.PostInSubreddit("author", "general", "Discuss")
+	parent, _ := e.CommentOnPost("author", "general", postID, "top")
+	reply, _ := e.ReplyToComment("general", postID, parent, "author", "reply")
+	e.ReplyToComment("general", postID, reply, "author", "deeper")
+
+	resp, err := http.Get(fmt.Sprintf("%s/posts/%d/comments?sort=new&depth=1", server.URL, postID))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var tree apis.CommentTreeResponse
+	json.NewDecoder(resp.Body).Decode(&tree)
+	if len(tree.Comments) != 1 || tree.Comments[0].More == nil || tree.Comments[0].More.Count != 2 {
+		t.Fatalf("expected a depth-limited tree with a stub, got %+v", tree)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/posts/%d/morechildren?children=%d", server.URL, postID, reply))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var more apis.CommentTreeResponse
+	json.NewDecoder(resp.Body).Decode(&more)
+	if len(more.Comments) != 1 || more.Comments[0].ID != reply || len(more.Comments[0].Replies) != 1 {
+		t.Errorf("expected expanding the stub to return the reply thread, got %+v", more)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/posts/%d/comments?sort=hot", server.URL, postID))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected unknown comment sort to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIEditAndDelete(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+	postID, _ := e.PostInSubreddit("alice", "general", "draft")
+	commentID, _ := e.CommentOnPost("bob", "general", postID, "nice")
+
+	postURL := fmt.Sprintf("%s/posts/%d", server.URL, postID)
+	resp := sendSigned(t, "PUT", postURL, client_rest.Comment{Content: "hijacked"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected non-author edit to be forbidden, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "PUT", postURL, client_rest.Comment{Content: "final"}, "alice", aliceKey)
+	var edited apis.PostResponse
+	json.NewDecoder(resp.Body).Decode(&edited)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || edited.Content != "final" || edited.Edited == nil || len(edited.Revisions) != 1 {
+		t.Errorf("unexpected edit response %d: %+v", resp.StatusCode, edited)
+	}
+
+	resp = sendSigned(t, "DELETE", fmt.Sprintf("%s/comments/%d", server.URL, commentID), nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected comment delete to succeed, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "DELETE", postURL, nil, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected post delete to succeed, got %d", resp.StatusCode)
+	}
+	if feed := fetchFeed(t, server.URL+"/posts?subreddit=general"); feed.Total != 0 {
+		t.Errorf("expected deleted post to leave the feed, got %+v", feed)
+	}
+}
+
+func TestAPIRichPosts(t *testing.T) {
+	e := engine.NewEngine()
+	e.CreateSubreddit("general")
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+
+	link := client_rest.Post{Subreddit: "general", Title: "Go memory model", Kind: "link", URL: "https://go.dev/ref/mem", Flair: "Discussion"}
+	resp := sendSigned(t, "POST", server.URL+"/post", link, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected link post to be created, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Title: "Broken", Kind: "video"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected unknown kind to be rejected, got %d", resp.StatusCode)
+	}
+
+	nsfw := client_rest.Post{Subreddit: "general", Title: "After dark", Content: "late night thread", NSFW: true}
+	body, _ := json.Marshal(nsfw)
+	req, _ := http.NewRequest("POST", server.URL+"/post", bytes.NewReader(body))
+	client_rest.SignRequest(req, body, "alice", aliceKey)
+	nsfw.NSFW = false
+	tampered, _ := json.Marshal(nsfw)
+	req.Body = io.NopCloser(bytes.NewReader(tampered))
+	req.ContentLength = int64(len(tampered))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a tampered NSFW flag to fail verification, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "general", Title: "After dark", NSFW: true, Spoiler: true}, "alice", aliceKey)
+	resp.Body.Close()
+
+	feed := fetchFeed(t, server.URL+"/posts?subreddit=general&nsfw=exclude")
+	if feed.Total != 1 || feed.Posts[0].Title != "Go memory model" || feed.Posts[0].Kind != "link" || feed.Posts[0].URL != "https://go.dev/ref/mem" {
+		t.Errorf("expected only the SFW link post, got %+v", feed)
+	}
+	feed = fetchFeed(t, server.URL+"/posts?subreddit=general&nsfw=only")
+	if feed.Total != 1 || !feed.Posts[0].NSFW || !feed.Posts[0].Spoiler {
+		t.Errorf("expected only the NSFW post, got %+v", feed)
+	}
+	feed = fetchFeed(t, server.URL+"/posts?flair=Discussion")
+	if feed.Total != 1 || feed.Posts[0].Flair != "Discussion" {
+		t.Errorf("expected flair filter to apply, got %+v", feed)
+	}
+
+	resp, err = http.Get(server.URL + "/posts?nsfw=sometimes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid nsfw filter to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIModerators(t *testing.T) {
+	e := engine.NewEngine()
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+
+	resp := sendSigned(t, "POST", server.URL+"/r/golang", nil, "alice", aliceKey)
+	resp.Body.Close()
+
+	resp = sendSigned(t, "PUT", server.URL+"/r/golang/moderators/alice", client_rest.ModeratorRequest{Permissions: []string{"posts"}}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a non-owner to be forbidden from adding moderators, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "PUT", server.URL+"/r/golang/moderators/bob", client_rest.ModeratorRequest{Permissions: []string{"sorcery"}}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an unknown permission to be rejected, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "PUT", server.URL+"/r/golang/moderators/bob", client_rest.ModeratorRequest{Permissions: []string{"posts", "users"}}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the owner to add a moderator, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(server.URL + "/r/golang/moderators")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var listing struct {
+		Moderators []apis.ModeratorResponse `json:"moderators"`
+	}
+	json.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	if len(listing.Moderators) != 2 || !listing.Moderators[0].Owner || listing.Moderators[1].Username != "bob" ||
+		strings.Join(listing.Moderators[1].Permissions, ",") != "posts,users" {
+		t.Errorf("unexpected moderator listing: %+v", listing.Moderators)
+	}
+
+	resp = sendSigned(t, "PATCH", server.URL+"/r/golang", client_rest.SubredditSettings{Description: "hijacked"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a moderator without config to be forbidden, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "PATCH", server.URL+"/r/golang", client_rest.SubredditSettings{Description: "Gophers welcome"}, "alice", aliceKey)
+	var info apis.SubredditResponse
+	json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || info.Owner != "alice" || info.Description != "Gophers welcome" {
+		t.Errorf("unexpected settings response %d: %+v", resp.StatusCode, info)
+	}
+
+	resp = sendSigned(t, "DELETE", server.URL+"/r/golang/moderators/bob", nil, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || e.HasPermission("golang", "bob", engine.PermPosts) {
+		t.Errorf("expected the owner to remove a moderator, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "DELETE", server.URL+"/r/golang/moderators/bob", nil, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected removing a non-moderator to 404, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/leave", nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected leaving a subreddit without membership to 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIBans(t *testing.T) {
+	e := engine.NewEngine()
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+	resp := sendSigned(t, "POST", server.URL+"/r/golang", nil, "alice", aliceKey)
+	resp.Body.Close()
+
+	ban := client_rest.Restriction{Username: "alice", Reason: "coup"}
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/bans", ban, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a non-moderator ban to be forbidden, got %d", resp.StatusCode)
+	}
+
+	ban = client_rest.Restriction{Username: "bob", Reason: "spam", Duration: "forever"}
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/bans", ban, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an unparseable duration to be rejected, got %d", resp.StatusCode)
+	}
+
+	ban.Duration = "24h"
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/bans", ban, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the owner to ban, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/join", nil, "bob", bobKey)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "spam") {
+		t.Errorf("expected a banned join to be forbidden with the reason, got %d: %s", resp.StatusCode, body)
+	}
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "golang", Content: "let me in"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a banned post to be forbidden, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "GET", server.URL+"/r/golang/bans", nil, "alice", aliceKey)
+	var listing struct {
+		Bans []apis.RestrictionResponse `json:"bans"`
+	}
+	json.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	if len(listing.Bans) != 1 || listing.Bans[0].Username != "bob" || listing.Bans[0].Expires == nil {
+		t.Errorf("unexpected ban listing: %+v", listing.Bans)
+	}
+
+	resp = sendSigned(t, "DELETE", server.URL+"/r/golang/bans/bob", nil, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected unban to succeed, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "DELETE", server.URL+"/r/golang/bans/bob", nil, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected unbanning twice to 404, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/r/golang/mutes", client_rest.Restriction{Username: "bob"}, "alice", aliceKey)
+	resp.Body.Close()
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "golang", Content: "muted"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a muted post to be forbidden, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPISubredditTypes(t *testing.T) {
+	e := engine.NewEngine()
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+
+	resp := sendSigned(t, "POST", server.URL+"/r/secret", client_rest.SubredditRequest{Type: "hidden"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an unknown type to be rejected, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", server.URL+"/r/secret", client_rest.SubredditRequest{Type: "private"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected private subreddit creation to succeed, got %d", resp.StatusCode)
+	}
+	postID, _ := e.PostInSubreddit("alice", "secret", "members only")
+
+	resp, _ = http.Get(server.URL + "/r/secret")
+	var info apis.SubredditResponse
+	json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	if info.Type != "private" {
+		t.Errorf("expected the type to be reported, got %+v", info)
+	}
+
+	for _, path := range []string{"/posts?subreddit=secret", fmt.Sprintf("/posts/%d", postID), fmt.Sprintf("/posts/%d/comments", postID), fmt.Sprintf("/r/secret/posts/%d/comments", postID), "/r/secret/members", "/r/secret/moderators"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected anonymous GET %s to be forbidden, got %d", path, resp.StatusCode)
+		}
+	}
+	if feed := fetchFeed(t, server.URL+"/posts"); feed.Total != 0 {
+		t.Errorf("expected private posts to stay out of the global feed, got %+v", feed)
+	}
+
+	resp = sendSigned(t, "GET", fmt.Sprintf("%s/r/secret/posts/%d/vote", server.URL, postID), nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a non-member's vote lookup to be forbidden, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", server.URL+"/r/secret/join", nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected an uninvited join to be forbidden, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", server.URL+"/r/secret/invites", client_rest.SubredditUser{Username: "bob"}, "alice", aliceKey)
+	resp.Body.Close()
+	resp = sendSigned(t, "POST", server.URL+"/r/secret/join", nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected an invited join to succeed, got %d", resp.StatusCode)
+	}
+
+	resp = sendSigned(t, "GET", server.URL+"/posts?subreddit=secret", nil, "bob", bobKey)
+	var feed apis.FeedResponse
+	json.NewDecoder(resp.Body).Decode(&feed)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || feed.Total != 1 {
+		t.Errorf("expected members to read the private feed, got %d: %+v", resp.StatusCode, feed)
+	}
+
+	resp = sendSigned(t, "POST", server.URL+"/r/news", client_rest.SubredditRequest{Type: "restricted"}, "alice", aliceKey)
+	resp.Body.Close()
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "news", Content: "scoop"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected unapproved posts to be forbidden, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", server.URL+"/r/news/approved", client_rest.SubredditUser{Username: "bob"}, "alice", aliceKey)
+	resp.Body.Close()
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "news", Content: "scoop"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected approved posts to succeed, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIModQueue(t *testing.T) {
+	e := engine.NewEngine()
+	server := httptest.NewServer(apis.NewServer(apis.Config{Engine: e}))
+	defer server.Close()
+
+	aliceKey := registerAPIUser(t, server.URL, "alice")
+	bobKey := registerAPIUser(t, server.URL, "bob")
+	resp := sendSigned(t, "POST", server.URL+"/r/golang", nil, "alice", aliceKey)
+	resp.Body.Close()
+	postID, _ := e.PostInSubreddit("bob", "golang", "Generics are here")
+	commentID, _ := e.CommentOnPost("bob", "golang", postID, "first!")
+
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/posts/%d/report", server.URL, postID), client_rest.Report{}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a report without a reason to be rejected, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/posts/%d/report", server.URL, postID), client_rest.Report{Reason: "off-topic"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the report to succeed, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/posts/%d/report", server.URL, postID), client_rest.Report{Reason: "again"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected a duplicate report to conflict, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/comments/%d/report", server.URL, commentID), client_rest.Report{Reason: "rude"}, "alice", aliceKey)
+	resp.Body.Close()
+
+	resp = sendSigned(t, "GET", server.URL+"/r/golang/modqueue", nil, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected non-moderators to be denied the queue, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "GET", server.URL+"/r/golang/modqueue", nil, "alice", aliceKey)
+	var listing struct {
+		Queue []apis.QueueItemResponse `json:"queue"`
+	}
+	json.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	if len(listing.Queue) != 2 || listing.Queue[0].Reports[0].Reason != "off-topic" || listing.Queue[1].CommentID != commentID {
+		t.Fatalf("unexpected queue: %+v", listing.Queue)
+	}
+
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/posts/%d/moderate", server.URL, postID), client_rest.ModerationAction{Action: "nuke"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an unknown action to be rejected, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/comments/%d/moderate", server.URL, commentID), client_rest.ModerationAction{Action: "remove"}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected non-moderators to be unable to moderate, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/comments/%d/moderate", server.URL, commentID), client_rest.ModerationAction{Action: "remove"}, "alice", aliceKey)
+	resp.Body.Close()
+	resp = sendSigned(t, "POST", fmt.Sprintf("%s/posts/%d/moderate", server.URL, postID), client_rest.ModerationAction{Action: "remove"}, "alice", aliceKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the owner to remove the post, got %d", resp.StatusCode)
+	}
+
+	if feed := fetchFeed(t, server.URL+"/posts?subreddit=golang"); feed.Total != 0 {
+		t.Errorf("expected removed posts to leave the feed, got %+v", feed)
+	}
+	resp, _ = http.Get(fmt.Sprintf("%s/posts/%d", server.URL, postID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected removed posts to 404 for regular users, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "GET", server.URL+"/posts?subreddit=golang", nil, "alice", aliceKey)
+	var feed apis.FeedResponse
+	json.NewDecoder(resp.Body).Decode(&feed)
+	resp.Body.Close()
+	if feed.Total != 1 || !feed.Posts[0].Removed {
+		t.Errorf("expected moderators to see removed posts, got %+v", feed)
+	}
+	resp = sendSigned(t, "GET", fmt.Sprintf("%s/comments/%d", server.URL, commentID), nil, "alice", aliceKey)
+	var comment apis.CommentResponse
+	json.NewDecoder(resp.Body).Decode(&comment)
+	resp.Body.Close()
+	if comment.Content != "first!" || !comment.Removed {
+		t.Errorf("expected moderators to see removed comments, got %+v", comment)
+	}
+
+	resp = sendSigned(t, "PUT", server.URL+"/r/golang/filter", client_rest.FilterWords{Words: []string{"spam"}}, "bob", bobKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected non-moderators to be unable to set filters, got %d", resp.StatusCode)
+	}
+	resp = sendSigned(t, "PUT", server.URL+"/r/golang/filter", client_rest.FilterWords{Words: []string{"spam"}}, "alice", aliceKey)
+	resp.Body.Close()
+	resp = sendSigned(t, "POST", server.URL+"/post", client_rest.Post{Subreddit: "golang", Content: "cheap spam"}, "bob", bobKey)
+	resp.Body.Close()
+	if feed := fetchFeed(t, server.URL+"/posts?subreddit=golang"); feed.Total != 0 {
+		t.Errorf("expected filtered posts to stay out of the feed, got %+v", feed)
+	}
+	resp = sendSigned(t, "GET", server.URL+"/r/golang/modqueue", nil, "alice", aliceKey)
+	listing.Queue = nil
+	json.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	if len(listing.Queue) != 1 || !listing.Queue[0].Filtered {
+		t.Errorf("expected the filtered post in the queue, got %+v", listing.Queue)
+	}
+}
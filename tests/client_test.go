@@ -1,4 +1,4 @@
-package client_test
+package tests
 
 import (
 	"project4/client"
@@ -34,6 +34,7 @@ func TestClientPosting(t *testing.T) {
 	user := client.NewClient("user1", e)
 
 	user.Register()
+	e.ConnectUser("user1")
 	e.CreateSubreddit("test_subreddit")
 
 	postID, err := user.PostInSubreddit("test_subreddit", "This is a test post.")
@@ -0,0 +1,1252 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"project4/engine"
+	"testing"
+	"time"
+)
+
+func TestRegisterUser(t *testing.T) {
+	e := engine.NewEngine()
+
+	err := e.RegisterUser("test_user")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = e.RegisterUser("test_user")
+	if err == nil {
+		t.Errorf("expected error for duplicate user, got none")
+	}
+}
+
+func TestSubredditManagement(t *testing.T) {
+	e := engine.NewEngine()
+
+	err := e.CreateSubreddit("test_subreddit")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = e.RegisterUser("test_user")
+	if err != nil {
+		t.Fatalf("unexpected error during user registration: %v", err)
+	}
+	err = e.JoinSubreddit("test_user", "test_subreddit")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = e.LeaveSubreddit("test_user", "test_subreddit")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPostInSubreddit(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	e.RegisterUser("test_user")
+	e.ConnectUser("test_user")
+
+	postID, err := e.PostInSubreddit("test_user", "test_subreddit", "Hello, world!")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if postID != 1 {
+		t.Errorf("expected post ID to be 1, got %d", postID)
+	}
+}
+
+func TestCommentOnPost(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	e.RegisterUser("test_user")
+	e.ConnectUser("test_user")
+	postID, _ := e.PostInSubreddit("test_user", "test_subreddit", "Hello, world!")
+
+	_, err := e.CommentOnPost("test_user", "test_subreddit", postID, "Nice post!")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVoting(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	e.RegisterUser("test_user")
+	e.ConnectUser("test_user")
+	postID, _ := e.PostInSubreddit("test_user", "test_subreddit", "Vote on me!")
+
+	err := e.UpvotePost("test_user", "test_subreddit", postID)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = e.DownvotePost("test_user", "test_subreddit", postID)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMessaging(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.RegisterUser("user1")
+	e.RegisterUser("user2")
+
+	_, err := e.SendMessage("user1", "user2", "Hello!")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	messages, err := e.ListMessages("user2")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Content != "Hello!" {
+		t.Errorf("expected message content to be 'Hello!', got '%s'", messages[0].Content)
+	}
+}
+
+func TestVotePostRecordsVoter(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	e.RegisterUser("author")
+	e.RegisterUser("voter")
+	e.ConnectUser("author")
+	e.ConnectUser("voter")
+	postID, _ := e.PostInSubreddit("author", "test_subreddit", "Vote on me!")
+
+	e.VotePost("voter", "test_subreddit", postID, engine.VoteUp)
+	post, err := e.VotePost("voter", "test_subreddit", postID, engine.VoteUp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Upvotes != 1 {
+		t.Errorf("expected repeated upvote to count once, got %d", post.Upvotes)
+	}
+
+	post, _ = e.VotePost("voter", "test_subreddit", postID, engine.VoteDown)
+	if post.Upvotes != 0 || post.Downvotes != 1 {
+		t.Errorf("expected vote change to move the vote, got %d/%d", post.Upvotes, post.Downvotes)
+	}
+
+	post, _ = e.VotePost("voter", "test_subreddit", postID, engine.VoteNone)
+	if post.Upvotes != 0 || post.Downvotes != 0 {
+		t.Errorf("expected cleared vote to be removed, got %d/%d", post.Upvotes, post.Downvotes)
+	}
+}
+
+func TestVoteLedger(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	e.RegisterUser("author")
+	e.RegisterUser("voter")
+	e.ConnectUser("author")
+	e.ConnectUser("voter")
+	postID, _ := e.PostInSubreddit("author", "test_subreddit", "Vote on me!")
+
+	for i := 0; i < 1000; i++ {
+		e.UpvotePost("voter", "test_subreddit", postID)
+	}
+	direction, err := e.GetPostVote("voter", "test_subreddit", postID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if direction != engine.VoteUp {
+		t.Errorf("expected recorded vote to be up, got %d", direction)
+	}
+
+	e.DownvotePost("voter", "test_subreddit", postID)
+	e.UpvotePost("author", "test_subreddit", postID)
+	feed, _ := e.GetFeed("test_subreddit", engine.SortNew, 1)
+	if feed[0].Upvotes != 1 || feed[0].Downvotes != 1 {
+		t.Errorf("expected one vote per user, got %d/%d", feed[0].Upvotes, feed[0].Downvotes)
+	}
+
+	if err := e.RetractVote("voter", "test_subreddit", postID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	direction, _ = e.GetPostVote("voter", "test_subreddit", postID)
+	if direction != engine.VoteNone {
+		t.Errorf("expected retracted vote to be cleared, got %d", direction)
+	}
+	feed, _ = e.GetFeed("test_subreddit", engine.SortNew, 1)
+	if feed[0].Upvotes != 1 || feed[0].Downvotes != 0 {
+		t.Errorf("expected retraction to adjust totals, got %d/%d", feed[0].Upvotes, feed[0].Downvotes)
+	}
+}
+
+func TestCommentVotingDrivesKarma(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	for _, name := range []string{"author", "commenter", "voter"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	postID, _ := e.PostInSubreddit("author", "test_subreddit", "Discuss")
+	commentID, _ := e.CommentOnPost("commenter", "test_subreddit", postID, "Hot take")
+
+	if karma := e.ComputeKarma("commenter"); karma != 0 {
+		t.Errorf("expected unvoted comment to earn no karma, got %d", karma)
+	}
+
+	e.UpvoteComment("voter", "test_subreddit", postID, commentID)
+	e.UpvoteComment("voter", "test_subreddit", postID, commentID)
+	e.UpvoteComment("author", "test_subreddit", postID, commentID)
+	if karma := e.ComputeKarma("commenter"); karma != 2 {
+		t.Errorf("expected comment karma of 2, got %d", karma)
+	}
+
+	comment, err := e.VoteComment("voter", "test_subreddit", postID, commentID, engine.VoteDown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if comment.Upvotes != 1 || comment.Downvotes != 1 {
+		t.Errorf("expected vote change to move the vote, got %d/%d", comment.Upvotes, comment.Downvotes)
+	}
+	direction, _ := e.GetCommentVote("voter", "test_subreddit", postID, commentID)
+	if direction != engine.VoteDown {
+		t.Errorf("expected recorded comment vote to be down, got %d", direction)
+	}
+	if karma := e.ComputeKarma("commenter"); karma != 0 {
+		t.Errorf("expected comment karma of 0, got %d", karma)
+	}
+}
+
+func TestIncrementalKarma(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	for _, name := range []string{"author", "voter1", "voter2"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	postID, _ := e.PostInSubreddit("author", "test_subreddit", "Vote on me!")
+	commentID, _ := e.CommentOnPost("author", "test_subreddit", postID, "Me too!")
+
+	e.UpvotePost("voter1", "test_subreddit", postID)
+	e.UpvotePost("voter2", "test_subreddit", postID)
+	e.DownvotePost("voter2", "test_subreddit", postID)
+	e.DownvoteComment("voter1", "test_subreddit", postID, commentID)
+
+	karma, err := e.GetKarma("author")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if karma.Post != 0 || karma.Comment != -1 {
+		t.Errorf("expected post karma 0 and comment karma -1, got %+v", karma)
+	}
+	if total, _ := e.GetUserKarma("author"); total != -1 {
+		t.Errorf("expected total karma -1, got %d", total)
+	}
+	if mismatched := e.VerifyKarma(); len(mismatched) != 0 {
+		t.Errorf("expected maintained karma to match a full recompute, got %+v", mismatched)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.UpdateAllUsersKarma()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("UpdateAllUsersKarma deadlocked")
+	}
+	if total, _ := e.GetUserKarma("author"); total != -1 {
+		t.Errorf("expected recompute to preserve karma -1, got %d", total)
+	}
+}
+
+func TestFeedSorts(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	voters := []string{"author", "voter1", "voter2", "voter3"}
+	for _, name := range voters {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	popular, _ := e.PostInSubreddit("author", "test_subreddit", "popular")
+	divisive, _ := e.PostInSubreddit("author", "test_subreddit", "divisive")
+	quiet, _ := e.PostInSubreddit("author", "test_subreddit", "quiet")
+
+	for _, voter := range voters[1:] {
+		e.UpvotePost(voter, "test_subreddit", popular)
+	}
+	e.UpvotePost("voter1", "test_subreddit", divisive)
+	e.UpvotePost("voter2", "test_subreddit", divisive)
+	e.DownvotePost("voter3", "test_subreddit", divisive)
+	e.DownvotePost("author", "test_subreddit", divisive)
+
+	expected := map[engine.FeedSort][]int{
+		engine.SortHot:           {popular, quiet, divisive},
+		engine.SortTop:           {popular, quiet, divisive},
+		engine.SortNew:           {quiet, divisive, popular},
+		engine.SortControversial: {divisive, quiet, popular},
+		engine.SortRising:        {popular, quiet, divisive},
+	}
+	for sortBy, ids := range expected {
+		feed, err := e.GetFeed("test_subreddit", sortBy, 10)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", sortBy, err)
+		}
+		for i, post := range feed {
+			if post.ID != ids[i] {
+				t.Errorf("expected %s order %v, got post %d at position %d", sortBy, ids, post.ID, i)
+				break
+			}
+		}
+	}
+
+	if _, err := engine.ParseFeedSort("upvotes"); !errors.Is(err, engine.ErrInvalidSort) {
+		t.Errorf("expected unknown sort to be rejected, got %v", err)
+	}
+	if sortBy, _ := engine.ParseFeedSort("Controversial"); sortBy != engine.SortControversial {
+		t.Errorf("expected controversial sort to parse, got %s", sortBy)
+	}
+}
+
+func TestFeedTimeWindows(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	e.RegisterUser("author")
+	e.RegisterUser("voter")
+	e.ConnectUser("author")
+	e.ConnectUser("voter")
+
+	now := time.Now()
+	ancient, _ := e.PostInSubredditAt("author", "test_subreddit", "ancient", now.Add(-400*24*time.Hour))
+	lastMonth, _ := e.PostInSubredditAt("author", "test_subreddit", "last month", now.Add(-20*24*time.Hour))
+	today, _ := e.PostInSubredditAt("author", "test_subreddit", "today", now.Add(-2*time.Hour))
+	e.UpvotePost("voter", "test_subreddit", ancient)
+	e.UpvotePost("author", "test_subreddit", ancient)
+	e.UpvotePost("voter", "test_subreddit", lastMonth)
+
+	expected := map[engine.TimeWindow][]int{
+		engine.WindowHour:  {},
+		engine.WindowDay:   {today},
+		engine.WindowWeek:  {today},
+		engine.WindowMonth: {lastMonth, today},
+		engine.WindowYear:  {lastMonth, today},
+		engine.WindowAll:   {ancient, lastMonth, today},
+	}
+	for window, ids := range expected {
+		page, err := e.QueryFeed("test_subreddit", engine.FeedOptions{Sort: engine.SortTop, Window: window})
+		feed := page.Posts
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", window, err)
+		}
+		if len(feed) != len(ids) {
+			t.Errorf("expected %d posts in the %s window, got %d", len(ids), window, len(feed))
+			continue
+		}
+		for i, post := range feed {
+			if post.ID != ids[i] {
+				t.Errorf("expected top of the %s to be %v, got post %d at position %d", window, ids, post.ID, i)
+				break
+			}
+		}
+	}
+
+	if _, err := engine.ParseTimeWindow("decade"); !errors.Is(err, engine.ErrInvalidTimeWindow) {
+		t.Errorf("expected unknown window to be rejected, got %v", err)
+	}
+}
+
+func TestFeedCursorPagination(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("test_subreddit")
+	e.RegisterUser("author")
+	e.ConnectUser("author")
+	for i := 0; i < 5; i++ {
+		e.PostInSubreddit("author", "test_subreddit", fmt.Sprintf("post %d", i))
+	}
+
+	options := engine.FeedOptions{Sort: engine.SortNew, Limit: 2}
+	first, err := e.QueryFeed("test_subreddit", options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Posts) != 2 || first.Total != 5 || first.After == "" || first.Before != "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+
+	e.PostInSubreddit("author", "test_subreddit", "inserted mid-scroll")
+
+	seen := map[int]bool{}
+	for _, post := range first.Posts {
+		seen[post.ID] = true
+	}
+	page := first
+	for page.After != "" {
+		options.After = page.After
+		page, err = e.QueryFeed("test_subreddit", options)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, post := range page.Posts {
+			if seen[post.ID] {
+				t.Errorf("post %d returned twice", post.ID)
+			}
+			seen[post.ID] = true
+		}
+	}
+	for id := 1; id <= 5; id++ {
+		if !seen[id] {
+			t.Errorf("post %d was skipped", id)
+		}
+	}
+	if page.Total != 6 || page.Before == "" {
+		t.Errorf("expected last page to count the insert and link back, got %+v", page)
+	}
+
+	back, err := e.QueryFeed("test_subreddit", engine.FeedOptions{Sort: engine.SortNew, Limit: 2, Before: page.Before})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(back.Posts) != 2 || back.Posts[0].ID != 3 || back.Posts[1].ID != 2 {
+		t.Errorf("expected previous page to hold posts 3 and 2, got %+v", back.Posts)
+	}
+
+	if _, err := e.QueryFeed("test_subreddit", engine.FeedOptions{Sort: engine.SortTop, After: first.After}); !errors.Is(err, engine.ErrInvalidCursor) {
+		t.Errorf("expected cursor from another sort to be rejected, got %v", err)
+	}
+	if _, err := e.QueryFeed("test_subreddit", engine.FeedOptions{Sort: engine.SortNew, After: "garbage!"}); !errors.Is(err, engine.ErrInvalidCursor) {
+		t.Errorf("expected malformed cursor to be rejected, got %v", err)
+	}
+}
+
+func TestFeedCursorOrdering(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("golang")
+	for _, name := range []string{"author", "voter1", "voter2", "voter3", "voter4"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	base := time.Now().Add(-time.Minute)
+	newest, _ := e.PostInSubredditAt("author", "golang", "newest", base.Add(2*time.Nanosecond))
+	middle, _ := e.PostInSubredditAt("author", "golang", "middle", base.Add(time.Nanosecond))
+	oldest, _ := e.PostInSubredditAt("author", "golang", "oldest", base)
+
+	seen := []int{}
+	options := engine.FeedOptions{Sort: engine.SortNew, Limit: 1}
+	for {
+		page, err := e.QueryFeed("golang", options)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, post := range page.Posts {
+			seen = append(seen, post.ID)
+		}
+		if page.After == "" {
+			break
+		}
+		options.After = page.After
+	}
+	if fmt.Sprint(seen) != fmt.Sprint([]int{newest, middle, oldest}) {
+		t.Errorf("expected nanosecond-apart posts in timestamp order, got %v", seen)
+	}
+
+	vote := func(voter string, postID int) {
+		if err := e.UpvotePost(voter, "golang", postID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	vote("voter1", oldest)
+	vote("voter2", oldest)
+	vote("voter1", middle)
+	first, _ := e.QueryFeed("golang", engine.FeedOptions{Sort: engine.SortRising, Limit: 1})
+	if len(first.Posts) != 1 || first.Posts[0].ID != oldest {
+		t.Fatalf("expected the fastest riser first, got %+v", first.Posts)
+	}
+	vote("voter3", oldest)
+	vote("voter4", oldest)
+	vote("voter2", middle)
+	vote("voter3", middle)
+	second, _ := e.QueryFeed("golang", engine.FeedOptions{Sort: engine.SortRising, Limit: 1, After: first.After})
+	if len(second.Posts) != 1 || second.Posts[0].ID != middle {
+		t.Errorf("expected the rising cursor to resume after its post, got %+v", second.Posts)
+	}
+}
+
+func TestHomeFeed(t *testing.T) {
+	e := engine.NewEngine()
+
+	for _, name := range []string{"golang", "movies", "cooking"} {
+		e.CreateSubreddit(name)
+	}
+	e.RegisterUser("reader")
+	e.RegisterUser("author")
+	e.ConnectUser("author")
+	e.JoinSubreddit("reader", "golang")
+	e.JoinSubreddit("reader", "movies")
+	e.JoinSubreddit("reader", "cooking")
+	e.LeaveSubreddit("reader", "cooking")
+
+	goPost, _ := e.PostInSubreddit("author", "golang", "generics")
+	moviePost, _ := e.PostInSubreddit("author", "movies", "sequels")
+	e.PostInSubreddit("author", "cooking", "pasta")
+
+	subscriptions, _ := e.ListSubscriptions("reader")
+	if len(subscriptions) != 2 || subscriptions[0] != "golang" || subscriptions[1] != "movies" {
+		t.Errorf("expected subscriptions to golang and movies, got %v", subscriptions)
+	}
+
+	page, err := e.GetHomeFeed("reader", engine.SortNew, "", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if page.Total != 2 || len(page.Posts) != 1 || page.Posts[0].ID != moviePost {
+		t.Fatalf("expected newest subscribed post first, got %+v", page)
+	}
+	page, _ = e.GetHomeFeed("reader", engine.SortNew, page.After, 1)
+	if len(page.Posts) != 1 || page.Posts[0].ID != goPost || page.After != "" {
+		t.Errorf("expected the golang post on the second page, got %+v", page)
+	}
+
+	if _, err := e.GetHomeFeed("ghost", engine.SortHot, "", 10); !errors.Is(err, engine.ErrUserNotFound) {
+		t.Errorf("expected unknown user to be rejected, got %v", err)
+	}
+}
+
+func TestPostAndCommentIndexes(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("golang")
+	e.CreateSubreddit("movies")
+	e.RegisterUser("author")
+	e.ConnectUser("author")
+	postID, _ := e.PostInSubreddit("author", "golang", "Discuss")
+
+	parentID := 0
+	ids := []int{}
+	for depth := 0; depth < 5; depth++ {
+		var id int
+		var err error
+		if depth == 0 {
+			id, err = e.CommentOnPost("author", "golang", postID, "top level")
+		} else {
+			id, err = e.ReplyToComment("golang", postID, parentID, "author", fmt.Sprintf("depth %d", depth))
+		}
+		if err != nil {
+			t.Fatalf("unexpected error replying at depth %d: %v", depth, err)
+		}
+		ids = append(ids, id)
+		parentID = id
+	}
+
+	comment, err := e.GetComment(ids[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if comment.PostID != postID || comment.ParentID != ids[0] || len(comment.Replies) != 1 {
+		t.Errorf("unexpected direct reply: %+v", comment)
+	}
+	if _, err := e.VoteComment("author", "golang", postID, ids[4], engine.VoteUp); err != nil {
+		t.Errorf("expected deep reply to be votable, got %v", err)
+	}
+
+	post, err := e.GetPost(postID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Subreddit != "golang" || len(post.Comments) != 1 {
+		t.Errorf("unexpected post: %+v", post)
+	}
+
+	if _, err := e.CommentOnPost("author", "movies", postID, "wrong subreddit"); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected post lookup to respect the subreddit, got %v", err)
+	}
+	if _, err := e.GetPost(999); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected missing post to be reported, got %v", err)
+	}
+	if _, err := e.GetComment(999); !errors.Is(err, engine.ErrCommentNotFound) {
+		t.Errorf("expected missing comment to be reported, got %v", err)
+	}
+}
+
+func TestCommentTree(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("golang")
+	for _, name := range []string{"author", "voter1", "voter2", "voter3"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	postID, _ := e.PostInSubreddit("author", "golang", "Discuss")
+
+	quiet, _ := e.CommentOnPost("author", "golang", postID, "quiet")
+	loved, _ := e.CommentOnPost("author", "golang", postID, "loved")
+	fought, _ := e.CommentOnPost("author", "golang", postID, "fought over")
+	for _, voter := range []string{"voter1", "voter2", "voter3"} {
+		e.UpvoteComment(voter, "golang", postID, loved)
+	}
+	e.UpvoteComment("voter1", "golang", postID, fought)
+	e.DownvoteComment("voter2", "golang", postID, fought)
+
+	reply, _ := e.ReplyToComment("golang", postID, loved, "voter1", "agreed")
+	deep, _ := e.ReplyToComment("golang", postID, reply, "voter2", "strongly agreed")
+	e.ReplyToComment("golang", postID, deep, "voter3", "very strongly agreed")
+
+	expected := map[engine.CommentSort][]int{
+		engine.CommentBest:          {loved, fought, quiet},
+		engine.CommentTop:           {loved, quiet, fought},
+		engine.CommentNew:           {fought, loved, quiet},
+		engine.CommentControversial: {fought, quiet, loved},
+	}
+	for sortBy, ids := range expected {
+		tree, err := e.GetCommentTree(postID, sortBy, 0, 0)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", sortBy, err)
+		}
+		for i, node := range tree.Comments {
+			if node.ID != ids[i] {
+				t.Errorf("expected %s order %v, got comment %d at position %d", sortBy, ids, node.ID, i)
+				break
+			}
+		}
+	}
+
+	tree, _ := e.GetCommentTree(postID, engine.CommentBest, 2, 0)
+	top := tree.Comments[0]
+	if len(top.Replies) != 1 || top.Replies[0].ID != reply || top.Replies[0].Depth != 1 {
+		t.Fatalf("expected the reply at depth 1, got %+v", top.Replies)
+	}
+	stub := top.Replies[0].More
+	if stub == nil || stub.ParentID != reply || stub.Count != 2 || len(stub.Children) != 1 || stub.Children[0] != deep {
+		t.Errorf("expected a more-children stub for the truncated subtree, got %+v", stub)
+	}
+
+	more, err := e.GetMoreChildren(postID, stub.Children, engine.CommentBest, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(more.Comments) != 1 || more.Comments[0].Depth != 2 || len(more.Comments[0].Replies) != 1 {
+		t.Errorf("expected expanding the stub to return the deep thread, got %+v", more.Comments)
+	}
+
+	tree, _ = e.GetCommentTree(postID, engine.CommentBest, 0, 2)
+	if len(tree.Comments) != 1 || tree.More == nil || tree.More.Count != 2 {
+		t.Fatalf("expected the limit to truncate top-level comments, got %+v", tree)
+	}
+	if replyStub := tree.Comments[0].Replies[0].More; replyStub == nil || replyStub.Children[0] != deep {
+		t.Errorf("expected the limit to leave a stub under the reply, got %+v", tree.Comments[0].Replies[0])
+	}
+
+	if _, err := e.GetMoreChildren(postID, []int{deep, quiet}, engine.CommentBest, 0, 0); !errors.Is(err, engine.ErrInvalidChildren) {
+		t.Errorf("expected children with different parents to be rejected, got %v", err)
+	}
+	siblings, err := e.GetMoreChildren(postID, []int{quiet, fought}, engine.CommentNew, 0, 0)
+	if err != nil || len(siblings.Comments) != 2 || siblings.Comments[0].Depth != 0 || siblings.Comments[0].ParentID != 0 {
+		t.Errorf("expected top-level siblings at depth 0, got %+v, %v", siblings.Comments, err)
+	}
+
+	if _, err := e.GetCommentTree(999, engine.CommentBest, 0, 0); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected missing post to be reported, got %v", err)
+	}
+}
+
+func TestEditAndDelete(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("golang")
+	for _, name := range []string{"author", "other"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	postID, _ := e.PostInSubreddit("author", "golang", "first draft")
+	commentID, _ := e.CommentOnPost("author", "golang", postID, "typo")
+	replyID, _ := e.ReplyToComment("golang", postID, commentID, "other", "reply")
+	e.UpvoteComment("other", "golang", postID, commentID)
+	e.UpvotePost("other", "golang", postID)
+
+	if _, err := e.EditPost("other", postID, "hijacked"); !errors.Is(err, engine.ErrNotAuthor) {
+		t.Errorf("expected non-author edit to be rejected, got %v", err)
+	}
+	post, err := e.EditPost("author", postID, "final draft")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Content != "final draft" || post.Edited.IsZero() || len(post.Revisions) != 1 || post.Revisions[0].Content != "first draft" {
+		t.Errorf("expected edit to be recorded with history, got %+v", post)
+	}
+
+	comment, _ := e.EditComment("author", commentID, "fixed")
+	if comment.Content != "fixed" || len(comment.Revisions) != 1 {
+		t.Errorf("expected comment edit to be recorded, got %+v", comment)
+	}
+
+	if err := e.DeleteComment("other", commentID); !errors.Is(err, engine.ErrNotAuthor) {
+		t.Errorf("expected non-author delete to be rejected, got %v", err)
+	}
+	if err := e.DeleteComment("author", commentID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tombstone, _ := e.GetComment(commentID)
+	if !tombstone.Deleted || tombstone.Content != engine.DeletedMarker || tombstone.Author != engine.DeletedMarker {
+		t.Errorf("expected a tombstone, got %+v", tombstone)
+	}
+	if len(tombstone.Replies) != 1 || tombstone.Replies[0].ID != replyID {
+		t.Errorf("expected the tombstone to keep its replies, got %+v", tombstone.Replies)
+	}
+	if _, err := e.ReplyToComment("golang", postID, commentID, "other", "too late"); !errors.Is(err, engine.ErrCommentNotFound) {
+		t.Errorf("expected replies to a deleted comment to be rejected, got %v", err)
+	}
+
+	if err := e.DeletePost("author", postID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	feed, _ := e.GetFeed("golang", engine.SortNew, 10)
+	if len(feed) != 0 {
+		t.Errorf("expected deleted post to leave the feed, got %+v", feed)
+	}
+	if tree, err := e.GetCommentTree(postID, engine.CommentBest, 0, 0); err != nil || len(tree.Comments) != 1 {
+		t.Errorf("expected the deleted post's thread to stay readable, got %+v, %v", tree, err)
+	}
+	if karma, _ := e.GetKarma("author"); karma.Total() != 0 {
+		t.Errorf("expected deleted content to stop earning karma, got %+v", karma)
+	}
+	if mismatched := e.VerifyKarma(); len(mismatched) != 0 {
+		t.Errorf("expected karma to stay consistent after deletes, got %+v", mismatched)
+	}
+}
+
+func TestRichPosts(t *testing.T) {
+	e := engine.NewEngine()
+
+	e.CreateSubreddit("golang")
+	e.RegisterUser("poster")
+	e.ConnectUser("poster")
+
+	linkID, err := e.SubmitPost("poster", "golang", engine.PostDraft{
+		Title: "Release notes",
+		Kind:  engine.KindLink,
+		URL:   "https://go.dev/doc/go1.23",
+		Flair: "News",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nsfwID, _ := e.SubmitPost("poster", "golang", engine.PostDraft{Title: "Spicy take", Content: "tabs", NSFW: true, Spoiler: true, Flair: "Discussion"})
+	textID, _ := e.PostInSubreddit("poster", "golang", "Plain post\nwith a body")
+
+	link, _ := e.GetPost(linkID)
+	if link.Title != "Release notes" || link.Kind != engine.KindLink || link.URL != "https://go.dev/doc/go1.23" || link.Flair != "News" {
+		t.Errorf("expected link post fields to be stored, got %+v", link)
+	}
+	text, _ := e.GetPost(textID)
+	if text.Title != "Plain post" || text.Kind != engine.KindText {
+		t.Errorf("expected text post titled from its first line, got %+v", text)
+	}
+
+	invalid := []engine.PostDraft{
+		{Content: "   "},
+		{Title: "No URL", Kind: engine.KindLink},
+		{Title: "Bad URL", Kind: engine.KindImage, URL: "ftp://example.com/cat.png"},
+		{Title: "Text with URL", Kind: engine.KindText, URL: "https://example.com"},
+	}
+	for _, draft := range invalid {
+		if _, err := e.SubmitPost("poster", "golang", draft); !errors.Is(err, engine.ErrInvalidPost) {
+			t.Errorf("expected %+v to be rejected, got %v", draft, err)
+		}
+	}
+
+	page, _ := e.QueryFeed("golang", engine.FeedOptions{Sort: engine.SortNew, NSFW: engine.NSFWExclude})
+	if page.Total != 2 || page.Posts[0].ID != textID || page.Posts[1].ID != linkID {
+		t.Errorf("expected NSFW posts to be excluded, got %+v", page.Posts)
+	}
+	page, _ = e.QueryFeed("golang", engine.FeedOptions{Sort: engine.SortNew, NSFW: engine.NSFWOnly})
+	if page.Total != 1 || page.Posts[0].ID != nsfwID || !page.Posts[0].Spoiler {
+		t.Errorf("expected only the NSFW post, got %+v", page.Posts)
+	}
+	page, _ = e.QueryFeed("golang", engine.FeedOptions{Sort: engine.SortNew, Flair: "news"})
+	if page.Total != 1 || page.Posts[0].ID != linkID {
+		t.Errorf("expected flair filter to match case-insensitively, got %+v", page.Posts)
+	}
+	if _, err := e.QueryFeed("golang", engine.FeedOptions{NSFW: engine.NSFWFilter(9)}); !errors.Is(err, engine.ErrInvalidFilter) {
+		t.Errorf("expected an invalid NSFW filter to be rejected, got %v", err)
+	}
+}
+
+func TestModeratorPermissions(t *testing.T) {
+	e := engine.NewEngine()
+
+	for _, name := range []string{"owner", "janitor", "settings", "poster"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	if err := e.CreateSubredditAs("owner", "golang", engine.TypePublic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, _ := e.GetSubreddit("golang"); info.Owner != "owner" {
+		t.Errorf("expected the creator to own the subreddit, got %+v", info)
+	}
+
+	if err := e.AddModerator("janitor", "golang", "settings", engine.PermConfig); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected only the owner to add moderators, got %v", err)
+	}
+	if err := e.AddModerator("owner", "golang", "janitor", engine.PermPosts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.AddModerator("owner", "golang", "settings", engine.PermConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.AddModerator("owner", "golang", "ghost", engine.PermPosts); !errors.Is(err, engine.ErrUserNotFound) {
+		t.Errorf("expected unknown users to be rejected, got %v", err)
+	}
+	if err := e.AddModerator("owner", "golang", "poster", engine.PermNone); !errors.Is(err, engine.ErrInvalidPermission) {
+		t.Errorf("expected an empty permission set to be rejected, got %v", err)
+	}
+
+	moderators, _ := e.ListModerators("owner", "golang")
+	if len(moderators) != 3 || !moderators[0].Owner || moderators[0].Permissions != engine.PermAll || moderators[1].Username != "janitor" {
+		t.Errorf("expected owner first then moderators by name, got %+v", moderators)
+	}
+
+	if _, err := e.UpdateSubreddit("janitor", "golang", engine.SubredditSettings{Description: "nope"}); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected a posts moderator to be denied config changes, got %v", err)
+	}
+	if info, err := e.UpdateSubreddit("settings", "golang", engine.SubredditSettings{Description: "All things Go"}); err != nil || info.Description != "All things Go" {
+		t.Errorf("expected a config moderator to update settings, got %+v, %v", info, err)
+	}
+
+	e.JoinSubreddit("poster", "golang")
+	postID, _ := e.PostInSubreddit("poster", "golang", "off topic")
+	commentID, _ := e.CommentOnPost("poster", "golang", postID, "spam")
+	e.UpvotePost("owner", "golang", postID)
+	e.UpvoteComment("owner", "golang", postID, commentID)
+	if err := e.DeleteComment("settings", commentID); !errors.Is(err, engine.ErrNotAuthor) {
+		t.Errorf("expected a config moderator to be denied post removal, got %v", err)
+	}
+	if err := e.DeleteComment("janitor", commentID); err != nil {
+		t.Errorf("expected a posts moderator to remove comments, got %v", err)
+	}
+	if _, err := e.EditPost("janitor", postID, "rewritten"); !errors.Is(err, engine.ErrNotAuthor) {
+		t.Errorf("expected moderators to be unable to edit content, got %v", err)
+	}
+	if err := e.DeletePost("janitor", postID); err != nil {
+		t.Errorf("expected a posts moderator to remove posts, got %v", err)
+	}
+	if karma, _ := e.GetUserKarma("poster"); karma != 2 {
+		t.Errorf("expected moderator removal to keep the author's karma, got %d", karma)
+	}
+	if post, err := e.GetPostAs("janitor", postID); err != nil || post.Author != "poster" || !post.Removed || post.Comments[0].Author != "poster" || !post.Comments[0].Removed {
+		t.Errorf("expected moderator removal to keep the author, got %+v, %v", post, err)
+	}
+	if _, err := e.GetPost(postID); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected removed posts to be hidden from regular readers, got %v", err)
+	}
+
+	if err := e.RemoveModerator("janitor", "golang", "settings"); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected moderators to be unable to remove each other, got %v", err)
+	}
+	if err := e.RemoveModerator("settings", "golang", "settings"); err != nil {
+		t.Errorf("expected moderators to be able to step down, got %v", err)
+	}
+	if err := e.RemoveModerator("owner", "golang", "owner"); !errors.Is(err, engine.ErrNotModerator) {
+		t.Errorf("expected the owner to be irremovable, got %v", err)
+	}
+	if e.HasPermission("golang", "settings", engine.PermConfig) || !e.HasPermission("golang", "owner", engine.PermAll) {
+		t.Errorf("expected permissions to reflect the current moderator list")
+	}
+}
+
+func TestBansAndMutes(t *testing.T) {
+	e := engine.NewEngine()
+
+	for _, name := range []string{"owner", "mod", "troll", "lurker"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	e.CreateSubredditAs("owner", "golang", engine.TypePublic)
+	e.AddModerator("owner", "golang", "mod", engine.PermUsers)
+	e.JoinSubreddit("troll", "golang")
+	postID, _ := e.PostInSubreddit("owner", "golang", "welcome")
+	commentID, _ := e.CommentOnPost("owner", "golang", postID, "rules")
+
+	if err := e.BanUser("lurker", "golang", "troll", "spam", 0); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected non-moderators to be unable to ban, got %v", err)
+	}
+	if err := e.BanUser("mod", "golang", "owner", "coup", 0); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected moderators to be unbannable, got %v", err)
+	}
+	if err := e.BanUser("mod", "golang", "troll", "spam", -time.Second); !errors.Is(err, engine.ErrInvalidDuration) {
+		t.Errorf("expected a negative duration to be rejected, got %v", err)
+	}
+	if err := e.BanUser("mod", "golang", "troll", "spam", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := e.PostInSubreddit("troll", "golang", "more spam")
+	var banned *engine.BannedError
+	if !errors.As(err, &banned) || banned.Reason != "spam" || !banned.Expires.IsZero() {
+		t.Errorf("expected a permanent BannedError when posting, got %v", err)
+	}
+	if _, err := e.CommentOnPost("troll", "golang", postID, "spam"); !errors.Is(err, engine.ErrBanned) {
+		t.Errorf("expected banned users to be unable to comment, got %v", err)
+	}
+	if _, err := e.ReplyToComment("golang", postID, commentID, "troll", "spam"); !errors.Is(err, engine.ErrBanned) {
+		t.Errorf("expected banned users to be unable to reply, got %v", err)
+	}
+	if err := e.UpvotePost("troll", "golang", postID); !errors.Is(err, engine.ErrBanned) {
+		t.Errorf("expected banned users to be unable to vote on posts, got %v", err)
+	}
+	if err := e.DownvoteComment("troll", "golang", postID, commentID); !errors.Is(err, engine.ErrBanned) {
+		t.Errorf("expected banned users to be unable to vote on comments, got %v", err)
+	}
+	e.LeaveSubreddit("troll", "golang")
+	if err := e.JoinSubreddit("troll", "golang"); !errors.Is(err, engine.ErrBanned) {
+		t.Errorf("expected banned users to be unable to join, got %v", err)
+	}
+
+	if bans, err := e.ListBans("mod", "golang"); err != nil || len(bans) != 1 || bans[0].Moderator != "mod" {
+		t.Errorf("expected the ban to be listed, got %+v, %v", bans, err)
+	}
+	if err := e.UnbanUser("mod", "golang", "troll"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.UnbanUser("mod", "golang", "troll"); !errors.Is(err, engine.ErrNotBanned) {
+		t.Errorf("expected a second unban to fail, got %v", err)
+	}
+
+	if err := e.BanUser("mod", "golang", "lurker", "", 20*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.JoinSubreddit("lurker", "golang"); !errors.Is(err, engine.ErrBanned) {
+		t.Errorf("expected a temporary ban to apply, got %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if err := e.JoinSubreddit("lurker", "golang"); err != nil {
+		t.Errorf("expected the ban to expire, got %v", err)
+	}
+	if bans, _ := e.ListBans("owner", "golang"); len(bans) != 0 {
+		t.Errorf("expected expired bans to drop off the list, got %+v", bans)
+	}
+
+	if err := e.MuteUser("mod", "golang", "troll", "flamewar", time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = e.CommentOnPost("troll", "golang", postID, "one more thing")
+	var muted *engine.MutedError
+	if !errors.As(err, &muted) || muted.Expires.IsZero() {
+		t.Errorf("expected a timed MutedError when commenting, got %v", err)
+	}
+	if _, err := e.PostInSubreddit("troll", "golang", "new thread"); !errors.Is(err, engine.ErrMuted) {
+		t.Errorf("expected muted users to be unable to post, got %v", err)
+	}
+	if err := e.UpvotePost("troll", "golang", postID); err != nil {
+		t.Errorf("expected muted users to still vote, got %v", err)
+	}
+	if err := e.JoinSubreddit("troll", "golang"); err != nil {
+		t.Errorf("expected muted users to still join, got %v", err)
+	}
+	if err := e.UnmuteUser("mod", "golang", "troll"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := e.CommentOnPost("troll", "golang", postID, "sorry"); err != nil {
+		t.Errorf("expected unmuted users to comment again, got %v", err)
+	}
+}
+
+func TestSubredditTypes(t *testing.T) {
+	e := engine.NewEngine()
+
+	for _, name := range []string{"owner", "member", "outsider"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	e.CreateSubredditAs("owner", "announcements", engine.TypeRestricted)
+	e.CreateSubredditAs("owner", "secret", engine.TypePrivate)
+	e.CreateSubreddit("open")
+	if err := e.CreateSubredditAs("owner", "weird", engine.SubredditType(7)); !errors.Is(err, engine.ErrInvalidSubredditType) {
+		t.Errorf("expected an unknown type to be rejected, got %v", err)
+	}
+	if info, _ := e.GetSubreddit("secret"); info.Type != engine.TypePrivate {
+		t.Errorf("expected the type to be recorded at creation, got %+v", info)
+	}
+
+	if _, err := e.PostInSubreddit("outsider", "open", "anyone can post"); err != nil {
+		t.Errorf("expected public subreddits to accept any poster, got %v", err)
+	}
+
+	if err := e.JoinSubreddit("member", "announcements"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := e.PostInSubreddit("member", "announcements", "hello"); !errors.Is(err, engine.ErrNotApproved) {
+		t.Errorf("expected unapproved members to be unable to post, got %v", err)
+	}
+	noticeID, _ := e.PostInSubreddit("owner", "announcements", "rules")
+	if _, err := e.CommentOnPost("outsider", "announcements", noticeID, "noted"); err != nil {
+		t.Errorf("expected anyone to comment in a restricted subreddit, got %v", err)
+	}
+	if err := e.ApproveUser("member", "announcements", "member"); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected only moderators to approve, got %v", err)
+	}
+	e.ApproveUser("owner", "announcements", "member")
+	if _, err := e.PostInSubreddit("member", "announcements", "hello"); err != nil {
+		t.Errorf("expected approved users to post, got %v", err)
+	}
+	if page, err := e.QueryFeed("announcements", engine.FeedOptions{Sort: engine.SortNew}); err != nil || page.Total != 2 {
+		t.Errorf("expected restricted subreddits to be readable by anyone, got %+v, %v", page, err)
+	}
+
+	secretID, _ := e.PostInSubreddit("owner", "secret", "members only")
+	if err := e.JoinSubreddit("member", "secret"); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected joining without an invite to fail, got %v", err)
+	}
+	if _, err := e.PostInSubreddit("outsider", "secret", "let me in"); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected non-members to be unable to post, got %v", err)
+	}
+	if err := e.UpvotePost("outsider", "secret", secretID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected non-members to be unable to vote, got %v", err)
+	}
+	if _, err := e.QueryFeed("secret", engine.FeedOptions{Sort: engine.SortNew, Viewer: "outsider"}); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected non-members to be unable to read, got %v", err)
+	}
+	if err := e.CheckPostAccess("", secretID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected anonymous readers to be denied private posts, got %v", err)
+	}
+	if _, err := e.GetPost(secretID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected GetPost to deny private posts to non-members, got %v", err)
+	}
+	if _, err := e.GetPostAs("outsider", secretID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected non-members to be denied private posts, got %v", err)
+	}
+	secretCommentID, _ := e.CommentOnPost("owner", "secret", secretID, "welcome")
+	if _, err := e.GetComment(secretCommentID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected GetComment to deny private comments to non-members, got %v", err)
+	}
+	if _, err := e.GetComments("outsider", "secret", secretID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected GetComments to deny private comments to non-members, got %v", err)
+	}
+	if _, err := e.GetPostVote("outsider", "secret", secretID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected post votes in private subreddits to be hidden from non-members, got %v", err)
+	}
+	if _, err := e.GetCommentVote("outsider", "secret", secretID, secretCommentID); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected comment votes in private subreddits to be hidden from non-members, got %v", err)
+	}
+	if _, err := e.ListModerators("outsider", "secret"); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected the moderator list of a private subreddit to be hidden, got %v", err)
+	}
+	if comments, err := e.GetComments("owner", "secret", secretID); err != nil || len(comments) != 1 {
+		t.Errorf("expected moderators to read private comments, got %+v, %v", comments, err)
+	}
+	if page, _ := e.QueryAllFeed(engine.FeedOptions{Sort: engine.SortNew}); page.Total != 3 {
+		t.Errorf("expected private posts to be left out of r/all, got %d posts", page.Total)
+	}
+
+	e.InviteUser("owner", "secret", "member")
+	if err := e.JoinSubreddit("member", "secret"); err != nil {
+		t.Fatalf("expected the invite to admit the user, got %v", err)
+	}
+	if _, err := e.PostInSubreddit("member", "secret", "thanks for the invite"); err != nil {
+		t.Errorf("expected members to post, got %v", err)
+	}
+	if page, err := e.QueryFeed("secret", engine.FeedOptions{Sort: engine.SortNew, Viewer: "member"}); err != nil || page.Total != 2 {
+		t.Errorf("expected members to read, got %+v, %v", page, err)
+	}
+	if page, _ := e.QueryHomeFeed("member", engine.FeedOptions{Sort: engine.SortNew}); page.Total != 4 {
+		t.Errorf("expected the home feed to include the private subreddit, got %d posts", page.Total)
+	}
+
+	e.LeaveSubreddit("member", "secret")
+	if err := e.JoinSubreddit("member", "secret"); !errors.Is(err, engine.ErrPrivateSubreddit) {
+		t.Errorf("expected invites to be single use, got %v", err)
+	}
+}
+
+func TestModQueue(t *testing.T) {
+	e := engine.NewEngine()
+
+	for _, name := range []string{"owner", "mod", "alice", "bob"} {
+		e.RegisterUser(name)
+		e.ConnectUser(name)
+	}
+	e.CreateSubredditAs("owner", "golang", engine.TypePublic)
+	e.AddModerator("owner", "golang", "mod", engine.PermPosts)
+
+	postID, _ := e.PostInSubreddit("alice", "golang", "Generics are here")
+	commentID, _ := e.CommentOnPost("alice", "golang", postID, "first!")
+
+	if err := e.ReportPost("bob", postID, " "); !errors.Is(err, engine.ErrInvalidReport) {
+		t.Errorf("expected a reason to be required, got %v", err)
+	}
+	if err := e.ReportPost("bob", postID, "off-topic"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.ReportPost("bob", postID, "spam"); !errors.Is(err, engine.ErrAlreadyReported) {
+		t.Errorf("expected duplicate reports to be rejected, got %v", err)
+	}
+	e.ReportPost("owner", postID, "low effort")
+	e.ReportComment("bob", commentID, "rude")
+
+	if _, err := e.GetModQueue("alice", "golang"); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected non-moderators to be denied the queue, got %v", err)
+	}
+	queue, err := e.GetModQueue("mod", "golang")
+	if err != nil || len(queue) != 2 {
+		t.Fatalf("expected the reported post and comment to be queued, got %+v, %v", queue, err)
+	}
+	if queue[0].PostID != postID || queue[0].CommentID != 0 || len(queue[0].Reports) != 2 || queue[0].Reports[0].Reason != "off-topic" {
+		t.Errorf("expected the post and its reports first, got %+v", queue[0])
+	}
+	if queue[1].CommentID != commentID || queue[1].Reports[0].Reporter != "bob" {
+		t.Errorf("expected the reported comment second, got %+v", queue[1])
+	}
+	if post, _ := e.GetPostAs("bob", postID); len(post.Reports) != 0 {
+		t.Errorf("expected reports to be hidden from regular users, got %+v", post.Reports)
+	}
+
+	if err := e.ModeratePost("alice", postID, engine.ModRemove); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected non-moderators to be unable to moderate, got %v", err)
+	}
+	if err := e.ModeratePost("mod", postID, engine.ModIgnore); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.ReportPost("alice", postID, "again")
+	if queue, _ := e.GetModQueue("mod", "golang"); len(queue) != 1 || queue[0].CommentID != commentID {
+		t.Errorf("expected ignored posts to stay out of the queue, got %+v", queue)
+	}
+
+	if err := e.ModerateComment("mod", commentID, engine.ModRemove); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tree, _ := e.GetCommentTree(postID, engine.CommentBest, 0, 0)
+	if len(tree.Comments) != 1 || tree.Comments[0].Content != engine.RemovedMarker || tree.Comments[0].Author != engine.RemovedMarker {
+		t.Errorf("expected removed comments to be redacted, got %+v", tree.Comments)
+	}
+	tree, _ = e.GetCommentTreeAs("mod", postID, engine.CommentBest, 0, 0)
+	if len(tree.Comments) != 1 || tree.Comments[0].Content != "first!" || !tree.Comments[0].Removed {
+		t.Errorf("expected moderators to see removed comments, got %+v", tree.Comments)
+	}
+	posts, _ := e.GetFeed("golang", engine.SortNew, 10)
+	if len(posts) != 1 || len(posts[0].Comments) != 1 || posts[0].Comments[0].Content != engine.RemovedMarker || posts[0].Comments[0].Author != engine.RemovedMarker || len(posts[0].Comments[0].Reports) != 0 {
+		t.Errorf("expected removed comments to be redacted in the feed, got %+v", posts)
+	}
+	page, _ := e.QueryFeed("golang", engine.FeedOptions{Sort: engine.SortNew, Viewer: "mod"})
+	if len(page.Posts) != 1 || len(page.Posts[0].Comments) != 1 || page.Posts[0].Comments[0].Content != "first!" {
+		t.Errorf("expected moderators to see removed comments in the feed, got %+v", page.Posts)
+	}
+	if comments, _ := e.GetComments("bob", "golang", postID); len(comments) != 1 || comments[0].Content != engine.RemovedMarker {
+		t.Errorf("expected GetComments to redact removed comments, got %+v", comments)
+	}
+	if comments, _ := e.GetComments("mod", "golang", postID); len(comments) != 1 || comments[0].Content != "first!" || !comments[0].Removed {
+		t.Errorf("expected moderators to see removed comments in GetComments, got %+v", comments)
+	}
+	if comment, _ := e.GetComment(commentID); comment.Content != engine.RemovedMarker || comment.Author != engine.RemovedMarker || len(comment.Reports) != 0 {
+		t.Errorf("expected GetComment to redact removed comments, got %+v", comment)
+	}
+	if err := e.ReportComment("bob", commentID, "rude"); !errors.Is(err, engine.ErrCommentNotFound) {
+		t.Errorf("expected removed comments to be unreportable, got %v", err)
+	}
+
+	e.ModeratePost("mod", postID, engine.ModRemove)
+	if posts, _ := e.GetFeed("golang", engine.SortNew, 10); len(posts) != 0 {
+		t.Errorf("expected removed posts to leave the feed, got %+v", posts)
+	}
+	if page, _ := e.QueryFeed("golang", engine.FeedOptions{Sort: engine.SortNew, Viewer: "mod"}); page.Total != 1 || !page.Posts[0].Removed {
+		t.Errorf("expected moderators to still see removed posts, got %+v", page)
+	}
+	if _, err := e.GetPostAs("bob", postID); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected removed posts to be hidden from regular users, got %v", err)
+	}
+	e.JoinSubreddit("mod", "golang")
+	e.JoinSubreddit("bob", "golang")
+	if page, _ := e.GetHomeFeed("mod", engine.SortNew, "", 10); page.Total != 1 || !page.Posts[0].Removed || page.Posts[0].Comments[0].Content != "first!" {
+		t.Errorf("expected moderators to see removed posts in their home feed, got %+v", page)
+	}
+	if page, _ := e.GetHomeFeed("bob", engine.SortNew, "", 10); page.Total != 0 {
+		t.Errorf("expected removed posts to leave regular home feeds, got %+v", page)
+	}
+	if comments, err := e.GetComments("mod", "golang", postID); err != nil || len(comments) != 1 || comments[0].Content != "first!" {
+		t.Errorf("expected moderators to read comments on removed posts, got %+v, %v", comments, err)
+	}
+	if _, err := e.GetComments("bob", "golang", postID); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected comments on removed posts to be hidden, got %v", err)
+	}
+	if _, err := e.GetPost(postID); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected GetPost to hide removed posts, got %v", err)
+	}
+	if post, err := e.GetPostAs("mod", postID); err != nil || post.Content != "Generics are here" || post.Comments[0].Content != "first!" {
+		t.Errorf("expected moderators to read removed posts in full, got %+v, %v", post, err)
+	}
+	if _, err := e.CommentOnPost("bob", "golang", postID, "still here?"); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected removed posts to be locked, got %v", err)
+	}
+	e.ModeratePost("mod", postID, engine.ModApprove)
+	if posts, _ := e.GetFeed("golang", engine.SortNew, 10); len(posts) != 1 {
+		t.Errorf("expected approved posts to return to the feed, got %+v", posts)
+	}
+	if err := e.ModeratePost("mod", postID, engine.ModAction(9)); !errors.Is(err, engine.ErrInvalidModAction) {
+		t.Errorf("expected unknown actions to be rejected, got %v", err)
+	}
+
+	if err := e.SetFilterWords("mod", "golang", []string{"spam"}); !errors.Is(err, engine.ErrPermissionDenied) {
+		t.Errorf("expected filter words to need config permission, got %v", err)
+	}
+	e.SetFilterWords("owner", "golang", []string{" SPAM ", ""})
+	spamID, _ := e.PostInSubreddit("bob", "golang", "Cheap Spam for sale")
+	filteredID, _ := e.CommentOnPost("bob", "golang", postID, "more spam")
+	e.PostInSubreddit("owner", "golang", "a note about spam")
+	queue, _ = e.GetModQueue("owner", "golang")
+	if len(queue) != 2 || queue[0].PostID != spamID || !queue[0].Filtered || !queue[0].Removed || queue[1].CommentID != filteredID {
+		t.Errorf("expected filtered items to be queued as removed, got %+v", queue)
+	}
+	if posts, _ := e.GetFeed("golang", engine.SortNew, 10); len(posts) != 2 || posts[0].ID == spamID || posts[1].ID == spamID {
+		t.Errorf("expected filtered posts to be hidden and moderators exempt, got %+v", posts)
+	}
+	e.ModeratePost("owner", spamID, engine.ModApprove)
+	if queue, _ := e.GetModQueue("owner", "golang"); len(queue) != 1 {
+		t.Errorf("expected approval to clear the filter flag, got %+v", queue)
+	}
+
+	cleanID, _ := e.PostInSubreddit("alice", "golang", "clean post")
+	if _, err := e.EditPost("alice", cleanID, "buy spam now"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := e.GetPost(cleanID); !errors.Is(err, engine.ErrPostNotFound) {
+		t.Errorf("expected editing in a filtered word to remove the post, got %v", err)
+	}
+	cleanCommentID, _ := e.CommentOnPost("alice", "golang", postID, "clean comment")
+	if _, err := e.EditComment("alice", cleanCommentID, "spam spam"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if comment, _ := e.GetCommentAs("owner", cleanCommentID); !comment.Removed || !comment.Filtered {
+		t.Errorf("expected editing in a filtered word to remove the comment, got %+v", comment)
+	}
+	queue, _ = e.GetModQueue("owner", "golang")
+	if len(queue) != 3 || queue[1].PostID != cleanID || queue[2].CommentID != cleanCommentID {
+		t.Errorf("expected edited items in the queue, got %+v", queue)
+	}
+	e.ModeratePost("owner", cleanID, engine.ModApprove)
+	e.ModerateComment("owner", cleanCommentID, engine.ModApprove)
+
+	parentID, _ := e.CommentOnPost("alice", "golang", postID, "parent")
+	replyID, _ := e.ReplyToComment("golang", postID, parentID, "alice", "nested")
+	e.ReportComment("bob", replyID, "rude")
+	queue, _ = e.GetModQueue("owner", "golang")
+	if len(queue) != 2 || queue[1].CommentID != replyID {
+		t.Errorf("expected nested replies to reach the queue, got %+v", queue)
+	}
+
+	if mismatched := e.VerifyKarma(); len(mismatched) != 0 {
+		t.Errorf("expected karma to stay consistent, got %+v", mismatched)
+	}
+}